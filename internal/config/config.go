@@ -1 +1,84 @@
 package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Config holds zip's settings, read from the zip.* entries of git config so
+// they can be set per repository or globally:
+//
+//	git config --global zip.token <token>
+//	git config zip.github.example.com.token <token>
+type Config struct {
+	values map[string]string
+}
+
+// Load reads the zip.* git config entries visible from dir. An empty dir
+// means the current working directory.
+func Load(dir string) (*Config, error) {
+	cmd := exec.Command("git", "config", "-z", "--get-regexp", `^zip\.`)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && exitError.ExitCode() == 1 {
+		// Exit code 1 means no matching entries.
+		return &Config{values: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git config: %w", err)
+	}
+
+	return Parse(out), nil
+}
+
+// Parse parses the output of `git config -z --get-regexp`.
+func Parse(out []byte) *Config {
+	values := make(map[string]string)
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		key, value, _ := strings.Cut(string(entry), "\n")
+		// Later entries (e.g. repo config over global) take precedence.
+		values[strings.ToLower(strings.TrimPrefix(key, "zip."))] = value
+	}
+	return &Config{values: values}
+}
+
+// Get returns the value of zip.<key>.
+func (c *Config) Get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	value, ok := c.values[strings.ToLower(key)]
+	return value, ok
+}
+
+// GetBool returns zip.<key> parsed as a boolean, or def if it isn't set.
+func (c *Config) GetBool(key string, def bool) (bool, error) {
+	value, ok := c.Get(key)
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("invalid boolean for zip.%s: %q", key, value)
+	}
+	return b, nil
+}
+
+// HostValue returns zip.<host>.<key>, falling back to zip.<key>.
+func (c *Config) HostValue(host, key string) (string, bool) {
+	if host != "" {
+		if value, ok := c.Get(host + "." + key); ok {
+			return value, true
+		}
+	}
+	return c.Get(key)
+}
@@ -0,0 +1,102 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const mergeQueueQuery = `
+query($owner: String!, $name: String!, $branch: String!) {
+  repository(owner: $owner, name: $name) {
+    mergeQueue(branch: $branch) { id }
+  }
+}`
+
+const enablePullRequestAutoMergeMutation = `
+mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) {
+    pullRequest { ...pullRequestFields }
+  }
+}` + pullRequestFields
+
+const enqueuePullRequestMutation = `
+mutation($id: ID!) {
+  enqueuePullRequest(input: {pullRequestId: $id}) {
+    mergeQueueEntry {
+      pullRequest { ...pullRequestFields }
+    }
+  }
+}` + pullRequestFields
+
+// EnableAutoMerge has GitHub merge a pull request once it can be merged. If
+// its base branch has a merge queue the pull request is added to the queue,
+// which merges with the queue's own method; otherwise auto-merge is enabled
+// with method. A pull request that can already be merged is merged right
+// away, since GitHub refuses to enable auto-merge for it.
+func (c *Client) EnableAutoMerge(ctx context.Context, ref PullRequestRef, method MergeMethod) (*PullRequest, error) {
+	// The mergeable state decides what to do, so don't trust the cache.
+	pr, err := c.GetPullRequest(Revalidate(ctx), ref.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	queued, err := c.hasMergeQueue(ctx, pr.BaseBranchName())
+	if err != nil {
+		return nil, err
+	}
+	if queued {
+		var data struct {
+			EnqueuePullRequest struct {
+				MergeQueueEntry struct {
+					PullRequest pullRequestNode `json:"pullRequest"`
+				} `json:"mergeQueueEntry"`
+			} `json:"enqueuePullRequest"`
+		}
+		if err := graphQL(ctx, c, enqueuePullRequestMutation, map[string]any{"id": pr.ID}, &data); err != nil {
+			return nil, fmt.Errorf("failed to add pull request #%d to the merge queue: %w", pr.Number, err)
+		}
+		return data.EnqueuePullRequest.MergeQueueEntry.PullRequest.toPullRequest(), nil
+	}
+
+	// GitHub refuses to enable auto-merge for a pull request that can
+	// already be merged.
+	if pr.MergeableState == "clean" {
+		return c.MergePullRequest(ctx, pr.Number, method, pr.HeadSHA)
+	}
+
+	var data struct {
+		EnablePullRequestAutoMerge struct {
+			PullRequest pullRequestNode `json:"pullRequest"`
+		} `json:"enablePullRequestAutoMerge"`
+	}
+	err = graphQL(ctx, c, enablePullRequestAutoMergeMutation, map[string]any{
+		"id":     pr.ID,
+		"method": strings.ToUpper(string(method)),
+	}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable auto-merge for pull request #%d: %w", pr.Number, err)
+	}
+	return data.EnablePullRequestAutoMerge.PullRequest.toPullRequest(), nil
+}
+
+// hasMergeQueue reports whether pull requests into branch go through a merge
+// queue.
+func (c *Client) hasMergeQueue(ctx context.Context, branch string) (bool, error) {
+	var data struct {
+		Repository struct {
+			MergeQueue *struct {
+				ID string `json:"id"`
+			} `json:"mergeQueue"`
+		} `json:"repository"`
+	}
+	err := graphQL(ctx, c, mergeQueueQuery, map[string]any{
+		"owner":  c.owner,
+		"name":   c.repo,
+		"branch": branch,
+	}, &data)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the merge queue for %s: %w", branch, err)
+	}
+	return data.Repository.MergeQueue != nil, nil
+}
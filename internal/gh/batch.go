@@ -0,0 +1,187 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// maxNodesPerQuery is GitHub's limit on the number of IDs passed to nodes().
+const maxNodesPerQuery = 100
+
+// pullRequestFields selects everything decoded into a pullRequestNode.
+const pullRequestFields = `
+fragment pullRequestFields on PullRequest {
+  id
+  number
+  url
+  state
+  isDraft
+  title
+  body
+  headRefName
+  headRefOid
+  baseRefName
+  reviewDecision
+  latestOpinionatedReviews(first: 100) {
+    nodes { state }
+  }
+  mergeStateStatus
+  isInMergeQueue
+  autoMergeRequest { enabledAt }
+  mergeCommit { oid }
+  commits(last: 1) {
+    nodes { commit { statusCheckRollup { state } } }
+  }
+}`
+
+const pullRequestNodesQuery = `
+query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    ...pullRequestFields
+  }
+}` + pullRequestFields
+
+// PullRequestRef identifies a pull request by node ID, number, or both.
+type PullRequestRef struct {
+	ID     string
+	Number int
+}
+
+type pullRequestNode struct {
+	ID               string `json:"id"`
+	Number           int    `json:"number"`
+	URL              string `json:"url"`
+	State            string `json:"state"`
+	IsDraft          bool   `json:"isDraft"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	HeadRefName      string `json:"headRefName"`
+	HeadRefOID       string `json:"headRefOid"`
+	BaseRefName      string `json:"baseRefName"`
+	ReviewDecision   string `json:"reviewDecision"`
+	MergeStateStatus string `json:"mergeStateStatus"`
+	IsInMergeQueue   bool   `json:"isInMergeQueue"`
+	AutoMergeRequest *struct {
+		EnabledAt string `json:"enabledAt"`
+	} `json:"autoMergeRequest"`
+	LatestReviews struct {
+		Nodes []struct {
+			State string `json:"state"`
+		} `json:"nodes"`
+	} `json:"latestOpinionatedReviews"`
+	MergeCommit *struct {
+		OID string `json:"oid"`
+	} `json:"mergeCommit"`
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					State string `json:"state"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+// GetStackPullRequests fetches every referenced pull request, using a single
+// GraphQL query per 100 node IDs. Refs without an ID, or any batch whose query
+// fails, fall back to REST calls per pull request, including LoadStatus for
+// open ones. Results keep the order of refs.
+func (c *Client) GetStackPullRequests(ctx context.Context, refs []PullRequestRef) ([]*PullRequest, error) {
+	result := make([]*PullRequest, len(refs))
+
+	var ids []string
+	index := make(map[string]int)
+	for i, ref := range refs {
+		if ref.ID != "" {
+			ids = append(ids, ref.ID)
+			index[ref.ID] = i
+		}
+	}
+
+	for start := 0; start < len(ids); start += maxNodesPerQuery {
+		end := min(start+maxNodesPerQuery, len(ids))
+		prs, err := c.getPullRequestNodes(ctx, ids[start:end])
+		if err != nil {
+			logrus.WithError(err).Debug("GraphQL batch fetch failed, falling back to REST")
+			continue
+		}
+		for _, pr := range prs {
+			if i, ok := index[pr.ID]; ok {
+				result[i] = pr
+			}
+		}
+	}
+
+	for i, ref := range refs {
+		if result[i] != nil {
+			continue
+		}
+		if ref.Number == 0 {
+			return nil, fmt.Errorf("failed to get pull request %s: no number to fall back on", ref.ID)
+		}
+		pr, err := c.GetPullRequest(ctx, ref.Number)
+		if err != nil {
+			return nil, err
+		}
+		if pr.State == "open" {
+			if err := c.LoadStatus(ctx, pr); err != nil {
+				logrus.WithError(err).Debug("Failed to load pull request status")
+			}
+		}
+		result[i] = pr
+	}
+
+	return result, nil
+}
+
+func (c *Client) getPullRequestNodes(ctx context.Context, ids []string) ([]*PullRequest, error) {
+	var data struct {
+		Nodes []*pullRequestNode `json:"nodes"`
+	}
+	if err := graphQL(ctx, c, pullRequestNodesQuery, map[string]any{"ids": ids}, &data); err != nil {
+		return nil, err
+	}
+
+	var prs []*PullRequest
+	for _, node := range data.Nodes {
+		// Deleted or inaccessible pull requests come back as null.
+		if node == nil || node.ID == "" {
+			continue
+		}
+		prs = append(prs, node.toPullRequest())
+	}
+	return prs, nil
+}
+
+func (n *pullRequestNode) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		ID:             n.ID,
+		Number:         n.Number,
+		HeadRefName:    n.HeadRefName,
+		HeadSHA:        n.HeadRefOID,
+		BaseRefName:    n.BaseRefName,
+		IsDraft:        n.IsDraft,
+		Permalink:      n.URL,
+		State:          strings.ToLower(n.State),
+		Title:          n.Title,
+		Body:           n.Body,
+		ReviewDecision: strings.ToLower(n.ReviewDecision),
+		MergeableState: strings.ToLower(n.MergeStateStatus),
+		AutoMerge:      n.IsInMergeQueue || n.AutoMergeRequest != nil,
+	}
+	for _, review := range n.LatestReviews.Nodes {
+		if review.State == "APPROVED" {
+			pr.Approvals++
+		}
+	}
+	if n.MergeCommit != nil {
+		pr.MergeCommit = n.MergeCommit.OID
+	}
+	if len(n.Commits.Nodes) > 0 && n.Commits.Nodes[0].Commit.StatusCheckRollup != nil {
+		pr.CheckStatus = strings.ToLower(n.Commits.Nodes[0].Commit.StatusCheckRollup.State)
+	}
+	return pr
+}
@@ -0,0 +1,266 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCacheTTL is how long a cached response is served without asking
+// GitHub at all. Older entries are revalidated with a conditional request,
+// which GitHub answers with a 304 that doesn't count against the rate limit.
+const DefaultCacheTTL = time.Minute
+
+// CacheDir returns where GitHub responses are cached for a repo, given its
+// git.Repo.ZipDir().
+func CacheDir(zipDir string) string {
+	return filepath.Join(zipDir, "cache", "github")
+}
+
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+// cacheTransport caches GET responses on disk and replays them for
+// conditional requests that come back 304 Not Modified.
+type cacheTransport struct {
+	base http.RoundTripper
+	dir  string
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// lastWrite is when this process last changed something on GitHub.
+	// Entries stored before it, or before lastWriteFile was touched by any
+	// process, are always revalidated.
+	lastWrite time.Time
+}
+
+// lastWriteFile is touched in the cache dir on every write, so a read
+// command run right after e.g. zip submit doesn't serve what was cached
+// before it.
+const lastWriteFile = "last-write"
+
+func newCacheTransport(base http.RoundTripper, dir string, ttl time.Duration) *cacheTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &cacheTransport{base: base, dir: dir, ttl: ttl}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		resp, err := t.base.RoundTrip(req)
+		if isWrite(req) {
+			t.markWrite()
+		}
+		return resp, err
+	}
+
+	key := t.key(req)
+	entry := t.load(key)
+	if entry != nil && !mustRevalidate(req) && t.isFresh(entry) {
+		logrus.WithField("url", req.URL.String()).Debug("serving GitHub response from cache")
+		return entry.response(req), nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		// Keep the fresh rate limit headers alongside the cached body.
+		for name, values := range resp.Header {
+			entry.Header[name] = values
+		}
+		entry.StoredAt = time.Now()
+		t.store(key, entry)
+		logrus.WithField("url", req.URL.String()).Debug("GitHub response not modified, replaying cache")
+		return entry.response(req), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || (resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(key, &cacheEntry{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   time.Now(),
+	})
+	return resp, nil
+}
+
+type revalidateKey struct{}
+
+// Revalidate returns a context whose GET requests are never answered from
+// the cache without asking GitHub, as if sent with Cache-Control: no-cache.
+// Cached entries are still revalidated with a conditional request, so
+// polling an unchanged resource stays cheap.
+func Revalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func mustRevalidate(req *http.Request) bool {
+	return req.Header.Get("Cache-Control") == "no-cache" || req.Context().Value(revalidateKey{}) != nil
+}
+
+// isWrite reports whether req may change something on GitHub. GraphQL is
+// always POSTed, so only mutations count there.
+func isWrite(req *http.Request) bool {
+	if req.Method == http.MethodHead {
+		return false
+	}
+	if !strings.HasSuffix(req.URL.Path, "/graphql") {
+		return true
+	}
+	if req.GetBody == nil {
+		return true
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return true
+	}
+	defer body.Close()
+	var payload graphQLRequest
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
+
+func (t *cacheTransport) markWrite() {
+	now := time.Now()
+	t.mu.Lock()
+	t.lastWrite = now
+	t.mu.Unlock()
+
+	path := filepath.Join(t.dir, lastWriteFile)
+	err := os.MkdirAll(t.dir, 0755)
+	if err == nil {
+		err = os.WriteFile(path, nil, 0600)
+	}
+	if err == nil {
+		err = os.Chtimes(path, now, now)
+	}
+	if err != nil {
+		logrus.WithError(err).Debug("failed to mark GitHub cache as written")
+	}
+}
+
+func (t *cacheTransport) isFresh(entry *cacheEntry) bool {
+	t.mu.Lock()
+	lastWrite := t.lastWrite
+	t.mu.Unlock()
+	if info, err := os.Stat(filepath.Join(t.dir, lastWriteFile)); err == nil && info.ModTime().After(lastWrite) {
+		lastWrite = info.ModTime()
+	}
+	return entry.StoredAt.After(lastWrite) && time.Since(entry.StoredAt) < t.ttl
+}
+
+// key identifies a response by URL, the representation asked for and a hash
+// of the credentials, so different tokens never share entries.
+func (t *cacheTransport) key(req *http.Request) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", req.URL.String(), req.Header.Get("Accept"), req.Header.Get("Authorization"))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *cacheTransport) path(key string) string {
+	return filepath.Join(t.dir, key[:2], key+".json")
+}
+
+func (t *cacheTransport) load(key string) *cacheEntry {
+	data, err := os.ReadFile(t.path(key))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logrus.WithError(err).Debug("ignoring corrupt GitHub cache entry")
+		return nil
+	}
+	if entry.Header == nil {
+		entry.Header = http.Header{}
+	}
+	return &entry
+}
+
+func (t *cacheTransport) store(key string, entry *cacheEntry) {
+	path := t.path(key)
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		// Write then rename so concurrent readers never see a partial entry.
+		tmp := path + ".tmp" + strconv.Itoa(os.Getpid())
+		if err = os.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).Debug("failed to write GitHub cache entry")
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	header.Set("X-From-Cache", "1")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// ClearCache removes every cached GitHub response under dir.
+func ClearCache(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear GitHub cache: %w", err)
+	}
+	return nil
+}
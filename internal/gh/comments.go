@@ -7,10 +7,24 @@ import (
 	"strings"
 )
 
-const stackCommentIdentifier = "This stack of pull requests is managed by zip."
+// StackCommentIdentifier marks the comment zip maintains on every pull request
+// of a stack, on any forge.
+const StackCommentIdentifier = "This stack of pull requests is managed by zip."
+
+// Comment is a top-level comment on a pull request.
+type Comment struct {
+	ID   int64
+	Body string
+	URL  string
+}
+
+// IsStackComment reports whether the comment is zip's stack comment
+func (c *Comment) IsStackComment() bool {
+	return strings.Contains(c.Body, StackCommentIdentifier)
+}
 
 // FindStackComment searches for the stack comment in a pull request
-func (c *Client) FindStackComment(ctx context.Context, number int) (*github.IssueComment, error) {
+func (c *Client) FindStackComment(ctx context.Context, number int) (*Comment, error) {
 	opts := &github.IssueListCommentsOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100,
@@ -24,8 +38,8 @@ func (c *Client) FindStackComment(ctx context.Context, number int) (*github.Issu
 		}
 
 		for _, comment := range comments {
-			if comment.Body != nil && strings.Contains(*comment.Body, stackCommentIdentifier) {
-				return comment, nil
+			if converted := convertToComment(comment); converted.IsStackComment() {
+				return converted, nil
 			}
 		}
 
@@ -38,8 +52,9 @@ func (c *Client) FindStackComment(ctx context.Context, number int) (*github.Issu
 	return nil, nil // Comment not found
 }
 
-// RemoveComment removes a specific comment from a pull request
-func (c *Client) RemoveComment(ctx context.Context, commentID int64) error {
+// RemoveComment removes a specific comment from a pull request. GitHub
+// comment IDs are unique per repository, so number is unused.
+func (c *Client) RemoveComment(ctx context.Context, number int, commentID int64) error {
 	_, err := c.api.Issues.DeleteComment(ctx, c.owner, c.repo, commentID)
 	if err != nil {
 		return fmt.Errorf("failed to delete comment: %w", err)
@@ -48,10 +63,42 @@ func (c *Client) RemoveComment(ctx context.Context, commentID int64) error {
 }
 
 // AddComment adds a new comment to a pull request
-func (c *Client) AddComment(ctx context.Context, number int, body string) (*github.IssueComment, error) {
+func (c *Client) AddComment(ctx context.Context, number int, body string) (*Comment, error) {
 	comment, _, err := c.api.Issues.CreateComment(ctx, c.owner, c.repo, number, &github.IssueComment{Body: &body})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
-	return comment, nil
+	return convertToComment(comment), nil
+}
+
+// UpdateComment replaces the body of an existing comment
+func (c *Client) UpdateComment(ctx context.Context, number int, commentID int64, body string) (*Comment, error) {
+	comment, _, err := c.api.Issues.EditComment(ctx, c.owner, c.repo, commentID, &github.IssueComment{Body: &body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	return convertToComment(comment), nil
+}
+
+// UpsertStackComment updates the stack comment on a pull request, creating it if missing
+func UpsertStackComment(ctx context.Context, f Forge, number int, body string) (*Comment, error) {
+	existing, err := f.FindStackComment(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return f.AddComment(ctx, number, body)
+	}
+	if existing.Body == body {
+		return existing, nil
+	}
+	return f.UpdateComment(ctx, number, existing.ID, body)
+}
+
+func convertToComment(comment *github.IssueComment) *Comment {
+	return &Comment{
+		ID:   comment.GetID(),
+		Body: comment.GetBody(),
+		URL:  comment.GetHTMLURL(),
+	}
 }
@@ -0,0 +1,73 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+)
+
+// The REST API ignores "draft" on edit, so draft state is toggled through
+// these GraphQL mutations instead.
+const convertToDraftMutation = `
+mutation($id: ID!) {
+  convertPullRequestToDraft(input: {pullRequestId: $id}) {
+    pullRequest { ...pullRequestFields }
+  }
+}` + pullRequestFields
+
+const markReadyForReviewMutation = `
+mutation($id: ID!) {
+  markPullRequestReadyForReview(input: {pullRequestId: $id}) {
+    pullRequest { ...pullRequestFields }
+  }
+}` + pullRequestFields
+
+// ConvertPullRequestToDraft converts a pull request to a draft.
+func (c *Client) ConvertPullRequestToDraft(ctx context.Context, ref PullRequestRef) (*PullRequest, error) {
+	id, err := c.nodeID(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		ConvertPullRequestToDraft struct {
+			PullRequest pullRequestNode `json:"pullRequest"`
+		} `json:"convertPullRequestToDraft"`
+	}
+	if err := graphQL(ctx, c, convertToDraftMutation, map[string]any{"id": id}, &data); err != nil {
+		return nil, fmt.Errorf("failed to convert pull request to a draft: %w", err)
+	}
+
+	return data.ConvertPullRequestToDraft.PullRequest.toPullRequest(), nil
+}
+
+// MarkPullRequestReadyForReview marks a pull request as ready for review.
+func (c *Client) MarkPullRequestReadyForReview(ctx context.Context, ref PullRequestRef) (*PullRequest, error) {
+	id, err := c.nodeID(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		MarkPullRequestReadyForReview struct {
+			PullRequest pullRequestNode `json:"pullRequest"`
+		} `json:"markPullRequestReadyForReview"`
+	}
+	if err := graphQL(ctx, c, markReadyForReviewMutation, map[string]any{"id": id}, &data); err != nil {
+		return nil, fmt.Errorf("failed to mark pull request as ready for review: %w", err)
+	}
+
+	return data.MarkPullRequestReadyForReview.PullRequest.toPullRequest(), nil
+}
+
+// nodeID returns the GraphQL node ID for ref, looking it up by number when
+// it wasn't stored.
+func (c *Client) nodeID(ctx context.Context, ref PullRequestRef) (string, error) {
+	if ref.ID != "" {
+		return ref.ID, nil
+	}
+	pr, err := c.GetPullRequest(ctx, ref.Number)
+	if err != nil {
+		return "", err
+	}
+	return pr.ID, nil
+}
@@ -0,0 +1,65 @@
+package gh
+
+import (
+	"strings"
+	"zip/internal/config"
+)
+
+type endpoints struct {
+	api     string
+	upload  string
+	graphql string
+}
+
+// IsEnterpriseHost reports whether host is something other than github.com.
+func IsEnterpriseHost(host string) bool {
+	host = strings.ToLower(host)
+	return host != "" && host != DefaultHost && host != "www."+DefaultHost
+}
+
+// resolveEndpoints returns the API endpoints for host, or nil for github.com.
+// zip.<host>.apiurl, zip.<host>.uploadurl and zip.<host>.graphqlurl override
+// the defaults for deployments behind unusual paths or proxies.
+func resolveEndpoints(cfg *config.Config, host string) *endpoints {
+	apiURL, hasAPI := cfg.Get(host + ".apiurl")
+	if !IsEnterpriseHost(host) && !hasAPI {
+		return nil
+	}
+
+	var urls endpoints
+	switch {
+	case hasAPI:
+		urls.api = withTrailingSlash(apiURL)
+		base := strings.TrimSuffix(urls.api, "v3/")
+		urls.upload = base + "uploads/"
+		if strings.HasSuffix(urls.api, "/api/v3/") {
+			urls.graphql = base + "graphql"
+		} else {
+			urls.graphql = urls.api + "graphql"
+		}
+	case strings.HasSuffix(strings.ToLower(host), ".ghe.com"):
+		// GitHub Enterprise Cloud with data residency uses api./uploads. subdomains.
+		urls.api = "https://api." + host + "/"
+		urls.upload = "https://uploads." + host + "/"
+		urls.graphql = urls.api + "graphql"
+	default:
+		urls.api = "https://" + host + "/api/v3/"
+		urls.upload = "https://" + host + "/api/uploads/"
+		urls.graphql = "https://" + host + "/api/graphql"
+	}
+
+	if upload, ok := cfg.Get(host + ".uploadurl"); ok {
+		urls.upload = withTrailingSlash(upload)
+	}
+	if graphql, ok := cfg.Get(host + ".graphqlurl"); ok {
+		urls.graphql = graphql
+	}
+	return &urls
+}
+
+func withTrailingSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
@@ -0,0 +1,32 @@
+package gh
+
+import "context"
+
+// Forge is a code hosting service that stacked pull requests are opened on.
+// *Client implements it for GitHub; other forges implement it in their own
+// packages and reuse PullRequest and Comment so storage stays forge-agnostic.
+type Forge interface {
+	GetPullRequest(ctx context.Context, number int) (*PullRequest, error)
+	GetPullRequests(ctx context.Context, input GetPullRequestsInput) ([]*PullRequest, error)
+	CreatePullRequest(ctx context.Context, title, body, head, base string, draft bool) (*PullRequest, error)
+	UpdatePullRequest(ctx context.Context, number int, title, body *string, state *string) (*PullRequest, error)
+	RetargetPullRequest(ctx context.Context, number int, base string) (*PullRequest, error)
+	RequestReviewers(ctx context.Context, number int, reviewers []string) (*PullRequest, error)
+	// MergePullRequest merges a pull request. If headSHA isn't empty the
+	// forge refuses the merge when the head has moved on from it, so a push
+	// since the caller checked the pull request isn't merged unseen.
+	MergePullRequest(ctx context.Context, number int, method MergeMethod, headSHA string) (*PullRequest, error)
+	EnableAutoMerge(ctx context.Context, ref PullRequestRef, method MergeMethod) (*PullRequest, error)
+	AllowedMergeMethods(ctx context.Context) ([]MergeMethod, error)
+	IsBranchMerged(ctx context.Context, branchName string) (bool, error)
+
+	ConvertPullRequestToDraft(ctx context.Context, ref PullRequestRef) (*PullRequest, error)
+	MarkPullRequestReadyForReview(ctx context.Context, ref PullRequestRef) (*PullRequest, error)
+
+	FindStackComment(ctx context.Context, number int) (*Comment, error)
+	AddComment(ctx context.Context, number int, body string) (*Comment, error)
+	UpdateComment(ctx context.Context, number int, commentID int64, body string) (*Comment, error)
+	RemoveComment(ctx context.Context, number int, commentID int64) error
+}
+
+var _ Forge = (*Client)(nil)
@@ -4,74 +4,189 @@ import (
 	"context"
 	"fmt"
 	"golang.org/x/oauth2"
-	"os/exec"
+	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
+	"zip/internal/config"
 
 	"github.com/google/go-github/v62/github"
 )
 
 type Client struct {
-	api   *github.Client
-	token string
-	ctx   context.Context
-	owner string
-	repo  string
+	api        *github.Client
+	graphqlURL string
+	token      string
+	host       string
+	ctx        context.Context
+	owner      string
+	repo       string
+	headOwner  string
 }
 
-var (
-	instance *Client
-	once     sync.Once
-)
+// Options configures a Client. Only Owner and Repo are required.
+type Options struct {
+	Owner string
+	Repo  string
+	// HeadOwner owns the fork branches are pushed to in a fork workflow.
+	// Defaults to Owner.
+	HeadOwner string
+	// Host is github.com (the default) or a GitHub Enterprise host.
+	Host string
+	// Tokens supplies the API token. Defaults to DefaultTokenChain for Host.
+	Tokens TokenProvider
+	// HTTPClient carries the authenticated requests. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// Config supplies token and endpoint settings. Loaded from Dir when nil.
+	Config *config.Config
+	// Dir is the repository directory used for config and git credentials.
+	// Defaults to the current working directory.
+	Dir string
+	// MaxRetries bounds retries of transient failures. Zero means the
+	// default; a negative value disables retries.
+	MaxRetries int
+	// CacheDir enables the on-disk response cache, see CacheDir().
+	CacheDir string
+	// CacheTTL is how long cached responses are used without revalidating.
+	// Defaults to DefaultCacheTTL.
+	CacheTTL time.Duration
+	// NoCache disables the response cache even if CacheDir is set.
+	NoCache bool
+	// WrapTransport, if set, wraps the transport above the retry and cache
+	// layers, so it sees each API call once with its final response.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+}
 
-// NewClient creates and returns a new GitHub client.
-func NewClient(owner, repo string) (*Client, error) {
-	var err error
-	once.Do(func() {
-		instance, err = initClient(owner, repo)
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Github client: %w", err)
+// New creates an independent GitHub client.
+func New(opts Options) (*Client, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, fmt.Errorf("failed to initialize GitHub client: owner and repo are required")
+	}
+	if opts.Host == "" {
+		opts.Host = DefaultHost
+	}
+	if opts.HeadOwner == "" {
+		opts.HeadOwner = opts.Owner
 	}
-	return instance, nil
-}
 
-func initClient(owner, repo string) (*Client, error) {
-	token, err := getGitHubToken()
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load(opts.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
+		}
+	}
+
+	tokens := opts.Tokens
+	if tokens == nil {
+		tokens = DefaultTokenChain(cfg, opts.Host, opts.Dir)
+	}
+	token, err := tokens.Token()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient(opts))
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 	tc := oauth2.NewClient(ctx, ts)
 
+	api := github.NewClient(tc)
+	graphqlURL := defaultGraphQLURL
+	if urls := resolveEndpoints(cfg, opts.Host); urls != nil {
+		// Assign the URLs as resolved: WithEnterpriseURLs would append
+		// api/v3/ and api/uploads/ to them.
+		if api.BaseURL, err = url.Parse(urls.api); err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL for %s: %w", opts.Host, err)
+		}
+		if api.UploadURL, err = url.Parse(urls.upload); err != nil {
+			return nil, fmt.Errorf("invalid GitHub upload URL for %s: %w", opts.Host, err)
+		}
+		graphqlURL = urls.graphql
+	}
+
 	return &Client{
-		api:   github.NewClient(tc),
-		token: token,
-		ctx:   ctx,
-		owner: owner,
-		repo:  repo,
+		api:        api,
+		graphqlURL: graphqlURL,
+		token:      token,
+		host:       opts.Host,
+		ctx:        context.Background(),
+		owner:      opts.Owner,
+		repo:       opts.Repo,
+		headOwner:  opts.HeadOwner,
 	}, nil
 }
 
-func getGitHubToken() (string, error) {
-	ghPath, err := exec.LookPath("gh")
-	if err != nil {
-		return "", fmt.Errorf("GitHub CLI not found: %w", err)
+// httpClient wraps the configured HTTP client's transport with retries and,
+// outside of those, the response cache.
+func httpClient(opts Options) *http.Client {
+	client := &http.Client{}
+	if opts.HTTPClient != nil {
+		*client = *opts.HTTPClient
 	}
 
-	out, err := exec.Command(ghPath, "auth", "token").Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get GitHub token: %w", err)
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	if maxRetries > 0 {
+		client.Transport = newRetryTransport(client.Transport, maxRetries)
+	}
+
+	if opts.CacheDir != "" && !opts.NoCache {
+		ttl := opts.CacheTTL
+		if ttl == 0 {
+			ttl = DefaultCacheTTL
+		}
+		client.Transport = newCacheTransport(client.Transport, opts.CacheDir, ttl)
+	}
+
+	if opts.WrapTransport != nil {
+		client.Transport = opts.WrapTransport(client.Transport)
 	}
+	return client
+}
+
+// NewClient creates a new GitHub client for a github.com repository.
+func NewClient(owner, repo string) (*Client, error) {
+	return New(Options{Owner: owner, Repo: repo})
+}
+
+// NewClientForHost creates a new GitHub client for a repository on the given
+// host, which may be github.com or a GitHub Enterprise Server.
+func NewClientForHost(host, owner, repo string) (*Client, error) {
+	return New(Options{Host: host, Owner: owner, Repo: repo})
+}
 
-	token := strings.TrimSpace(string(out))
-	if token == "" {
-		return "", fmt.Errorf("empty GitHub token received")
+var (
+	sharedMu      sync.Mutex
+	sharedClients = map[string]*Client{}
+)
+
+// Shared returns a process-wide client for the options' host, owner and
+// repo, creating it on first use. It is a convenience for single-repo
+// callers; failed initialisations are not cached, so a later call retries.
+func Shared(opts Options) (*Client, error) {
+	host := opts.Host
+	if host == "" {
+		host = DefaultHost
 	}
+	key := strings.ToLower(host + "/" + opts.Owner + "/" + opts.Repo)
 
-	return token, nil
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if client, ok := sharedClients[key]; ok {
+		return client, nil
+	}
+	client, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	sharedClients[key] = client
+	return client, nil
 }
 
 // GetOwner returns the owner of the repository.
@@ -84,6 +199,25 @@ func (c *Client) GetRepo() string {
 	return c.repo
 }
 
+// IsFork reports whether pull requests come from branches on a fork.
+func (c *Client) IsFork() bool {
+	return !strings.EqualFold(c.headOwner, c.owner)
+}
+
+// headRef returns how a branch is named as a pull request head, which is
+// "owner:branch" for branches on a fork.
+func (c *Client) headRef(branch string) string {
+	if c.IsFork() {
+		return c.headOwner + ":" + branch
+	}
+	return branch
+}
+
+// GetHost returns the host the client talks to.
+func (c *Client) GetHost() string {
+	return c.host
+}
+
 // GetContext returns the context used by the client.
 func (c *Client) GetContext() context.Context {
 	return c.ctx
@@ -0,0 +1,610 @@
+// Package ghfake provides an in-memory gh.Forge for exercising submit, sync
+// and merge flows without network access or credentials.
+package ghfake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"zip/internal/gh"
+)
+
+// Forge is an in-memory gh.Forge. Pull requests are numbered from 1 in
+// creation order. When RepoDir points at a (typically bare) git repository
+// standing in for the remote, merges create real commits on the base branch
+// there; otherwise they only change the pull request state.
+type Forge struct {
+	// RepoDir is the git directory of the simulated remote, if any.
+	RepoDir string
+	// DeleteBranchOnMerge mimics GitHub's "automatically delete head
+	// branches": the head branch is deleted and pull requests based on it
+	// are retargeted to the merged pull request's base.
+	DeleteBranchOnMerge bool
+	// MergeMethods are the merge methods the repository allows.
+	MergeMethods []gh.MergeMethod
+
+	mu        sync.Mutex
+	pulls     []*gh.PullRequest
+	comments  map[int][]*gh.Comment
+	reviewers map[int][]string
+	autoMerge map[int]gh.MergeMethod
+	nextID    int64
+	failures  map[string]error
+}
+
+var _ gh.Forge = (*Forge)(nil)
+
+// Error is a refusal with the HTTP status the real forge would answer with,
+// so callers can tell it apart with gh.StatusCode.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// HTTPStatus returns the status code, see gh.StatusCode.
+func (e *Error) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// notMergeable builds the error GitHub answers a merge it refuses with.
+func notMergeable(format string, args ...any) error {
+	return &Error{StatusCode: http.StatusMethodNotAllowed, Message: fmt.Sprintf(format, args...)}
+}
+
+// New returns an empty fake forge. repoDir may be empty.
+func New(repoDir string) *Forge {
+	return &Forge{
+		RepoDir:      repoDir,
+		MergeMethods: []gh.MergeMethod{gh.MergeMethodMerge, gh.MergeMethodSquash, gh.MergeMethodRebase},
+		comments:     make(map[int][]*gh.Comment),
+		reviewers:    make(map[int][]string),
+		autoMerge:    make(map[int]gh.MergeMethod),
+		failures:     make(map[string]error),
+	}
+}
+
+// FailNext makes the next call of the named method, e.g.
+// "CreatePullRequest", return err.
+func (f *Forge) FailNext(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = err
+}
+
+// SetMergeableState sets what the forge reports about whether a pull request
+// can be merged, e.g. "blocked" to simulate failing checks.
+func (f *Forge) SetMergeableState(number int, state string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, err := f.pull(number)
+	if err != nil {
+		return err
+	}
+	pr.MergeableState = state
+	return nil
+}
+
+// PullRequests returns copies of all pull requests, oldest first.
+func (f *Forge) PullRequests() []gh.PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := make([]gh.PullRequest, 0, len(f.pulls))
+	for _, pr := range f.pulls {
+		result = append(result, *pr)
+	}
+	return result
+}
+
+// Comments returns copies of the comments on a pull request, oldest first.
+func (f *Forge) Comments(number int) []gh.Comment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []gh.Comment
+	for _, c := range f.comments[number] {
+		result = append(result, *c)
+	}
+	return result
+}
+
+// Reviewers returns the reviewers requested on a pull request.
+func (f *Forge) Reviewers(number int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.reviewers[number])
+}
+
+func (f *Forge) GetPullRequest(ctx context.Context, number int) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetPullRequest"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	copied := copyOf(pr)
+	if f.RepoDir != "" && pr.State == "open" {
+		if copied.HeadSHA, err = f.git("rev-parse", "--verify", "refs/heads/"+pr.HeadRefName); err != nil {
+			return nil, err
+		}
+	}
+	return copied, nil
+}
+
+func (f *Forge) GetPullRequests(ctx context.Context, input gh.GetPullRequestsInput) ([]*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("GetPullRequests"); err != nil {
+		return nil, err
+	}
+
+	head := input.Head
+	if _, branch, found := strings.Cut(head, ":"); found {
+		head = branch
+	}
+	state := input.State
+	if state == "" {
+		state = "open"
+	}
+
+	var result []*gh.PullRequest
+	for _, pr := range f.pulls {
+		switch {
+		case state == "closed" && pr.State == "open",
+			state == "open" && pr.State != "open",
+			head != "" && pr.HeadRefName != head,
+			input.Base != "" && pr.BaseRefName != input.Base:
+			continue
+		}
+		result = append(result, copyOf(pr))
+	}
+	if input.Dir != "asc" {
+		slices.Reverse(result)
+	}
+	return result, nil
+}
+
+func (f *Forge) CreatePullRequest(ctx context.Context, title, body, head, base string, draft bool) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("CreatePullRequest"); err != nil {
+		return nil, err
+	}
+	if head == "" || base == "" || head == base {
+		return nil, fmt.Errorf("failed to create pull request: invalid head %q or base %q", head, base)
+	}
+	if _, branch, found := strings.Cut(head, ":"); found {
+		head = branch
+	}
+	for _, pr := range f.pulls {
+		if pr.State == "open" && pr.HeadRefName == head {
+			return nil, fmt.Errorf("failed to create pull request: a pull request already exists for %s", head)
+		}
+	}
+
+	f.nextID++
+	number := len(f.pulls) + 1
+	pr := &gh.PullRequest{
+		ID:             fmt.Sprintf("PR_%d", f.nextID),
+		Number:         number,
+		HeadRefName:    head,
+		BaseRefName:    base,
+		IsDraft:        draft,
+		Permalink:      fmt.Sprintf("https://forge.example/pull/%d", number),
+		State:          "open",
+		Title:          title,
+		Body:           body,
+		MergeableState: "clean",
+	}
+	f.pulls = append(f.pulls, pr)
+	return copyOf(pr), nil
+}
+
+func (f *Forge) UpdatePullRequest(ctx context.Context, number int, title, body *string, state *string) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("UpdatePullRequest"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	if title != nil {
+		pr.Title = *title
+	}
+	if body != nil {
+		pr.Body = *body
+	}
+	if state != nil {
+		if pr.State == "merged" {
+			return nil, fmt.Errorf("failed to update pull request: #%d is merged", number)
+		}
+		if *state != "open" && *state != "closed" {
+			return nil, fmt.Errorf("failed to update pull request: invalid state %q", *state)
+		}
+		pr.State = *state
+	}
+	return copyOf(pr), nil
+}
+
+func (f *Forge) RetargetPullRequest(ctx context.Context, number int, base string) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RetargetPullRequest"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	if pr.State != "open" {
+		return nil, fmt.Errorf("failed to change base of pull request to %s: #%d is %s", base, number, pr.State)
+	}
+	pr.BaseRefName = base
+	// Like GitHub, changing the base disables auto-merge.
+	pr.AutoMerge = false
+	delete(f.autoMerge, number)
+	return copyOf(pr), nil
+}
+
+func (f *Forge) RequestReviewers(ctx context.Context, number int, reviewers []string) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RequestReviewers"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	for _, reviewer := range reviewers {
+		if !slices.Contains(f.reviewers[number], reviewer) {
+			f.reviewers[number] = append(f.reviewers[number], reviewer)
+		}
+	}
+	return copyOf(pr), nil
+}
+
+func (f *Forge) MergePullRequest(ctx context.Context, number int, method gh.MergeMethod, headSHA string) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("MergePullRequest"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	if headSHA != "" && f.RepoDir != "" {
+		head, err := f.git("rev-parse", "--verify", "refs/heads/"+pr.HeadRefName)
+		if err != nil {
+			return nil, err
+		}
+		if head != headSHA {
+			return nil, &Error{
+				StatusCode: http.StatusConflict,
+				Message:    fmt.Sprintf("failed to %s pull request #%d: head branch was modified", method, number),
+			}
+		}
+	}
+	return f.merge(pr, method)
+}
+
+// EnableAutoMerge marks a pull request to be merged by ProcessAutoMerges.
+func (f *Forge) EnableAutoMerge(ctx context.Context, ref gh.PullRequestRef, method gh.MergeMethod) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("EnableAutoMerge"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(ref.Number)
+	if err != nil {
+		return nil, err
+	}
+	if pr.State != "open" {
+		return nil, notMergeable("failed to enable auto-merge for pull request #%d: it is %s", ref.Number, pr.State)
+	}
+	if !slices.Contains(f.MergeMethods, method) {
+		return nil, notMergeable("failed to enable auto-merge for pull request #%d: %s is not allowed in this repository", ref.Number, method)
+	}
+	pr.AutoMerge = true
+	f.autoMerge[ref.Number] = method
+	return copyOf(pr), nil
+}
+
+// ProcessAutoMerges merges every pull request with auto-merge enabled that
+// nothing blocks, as the forge would once checks pass, and returns their
+// numbers.
+func (f *Forge) ProcessAutoMerges() ([]int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var merged []int
+	for _, pr := range f.pulls {
+		method, ok := f.autoMerge[pr.Number]
+		if !ok || pr.State != "open" || pr.MergeBlocker() != "" {
+			continue
+		}
+		if _, err := f.merge(pr, method); err != nil {
+			return merged, err
+		}
+		merged = append(merged, pr.Number)
+	}
+	return merged, nil
+}
+
+// merge merges pr; f.mu must be held.
+func (f *Forge) merge(pr *gh.PullRequest, method gh.MergeMethod) (*gh.PullRequest, error) {
+	number := pr.Number
+	if pr.State != "open" {
+		return nil, notMergeable("failed to %s pull request #%d: it is %s", method, number, pr.State)
+	}
+	if !slices.Contains(f.MergeMethods, method) {
+		return nil, notMergeable("failed to %s pull request #%d: %s is not allowed in this repository", method, number, method)
+	}
+	if blocker := pr.MergeBlocker(); blocker != "" {
+		return nil, notMergeable("failed to %s pull request #%d: it %s", method, number, blocker)
+	}
+
+	mergeCommit := fmt.Sprintf("%040d", number)
+	if f.RepoDir != "" {
+		var err error
+		if mergeCommit, err = f.mergeInRepo(pr, method); err != nil {
+			return nil, fmt.Errorf("failed to %s pull request #%d: %w", method, number, err)
+		}
+	}
+	pr.State = "merged"
+	pr.MergeCommit = mergeCommit
+	pr.AutoMerge = false
+	delete(f.autoMerge, number)
+
+	if f.DeleteBranchOnMerge {
+		for _, child := range f.pulls {
+			if child.State == "open" && child.BaseRefName == pr.HeadRefName {
+				child.BaseRefName = pr.BaseRefName
+			}
+		}
+		if f.RepoDir != "" {
+			if _, err := f.git("update-ref", "-d", "refs/heads/"+pr.HeadRefName); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return copyOf(pr), nil
+}
+
+func (f *Forge) AllowedMergeMethods(ctx context.Context) ([]gh.MergeMethod, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("AllowedMergeMethods"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.MergeMethods), nil
+}
+
+func (f *Forge) IsBranchMerged(ctx context.Context, branchName string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("IsBranchMerged"); err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(f.pulls, func(pr *gh.PullRequest) bool {
+		return pr.HeadRefName == branchName && pr.State == "merged"
+	}), nil
+}
+
+func (f *Forge) ConvertPullRequestToDraft(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return f.setDraft("ConvertPullRequestToDraft", ref.Number, true)
+}
+
+func (f *Forge) MarkPullRequestReadyForReview(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return f.setDraft("MarkPullRequestReadyForReview", ref.Number, false)
+}
+
+func (f *Forge) setDraft(method string, number int, draft bool) (*gh.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail(method); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	if pr.State != "open" {
+		return nil, fmt.Errorf("pull request #%d is %s", number, pr.State)
+	}
+	pr.IsDraft = draft
+	return copyOf(pr), nil
+}
+
+func (f *Forge) FindStackComment(ctx context.Context, number int) (*gh.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("FindStackComment"); err != nil {
+		return nil, err
+	}
+	if _, err := f.pull(number); err != nil {
+		return nil, err
+	}
+	for _, c := range f.comments[number] {
+		if c.IsStackComment() {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *Forge) AddComment(ctx context.Context, number int, body string) (*gh.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("AddComment"); err != nil {
+		return nil, err
+	}
+	pr, err := f.pull(number)
+	if err != nil {
+		return nil, err
+	}
+	f.nextID++
+	c := &gh.Comment{
+		ID:   f.nextID,
+		Body: body,
+		URL:  fmt.Sprintf("%s#issuecomment-%d", pr.Permalink, f.nextID),
+	}
+	f.comments[number] = append(f.comments[number], c)
+	copied := *c
+	return &copied, nil
+}
+
+func (f *Forge) UpdateComment(ctx context.Context, number int, commentID int64, body string) (*gh.Comment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("UpdateComment"); err != nil {
+		return nil, err
+	}
+	for _, c := range f.comments[number] {
+		if c.ID == commentID {
+			c.Body = body
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to update comment: comment %d not found on #%d", commentID, number)
+}
+
+func (f *Forge) RemoveComment(ctx context.Context, number int, commentID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail("RemoveComment"); err != nil {
+		return err
+	}
+	comments := f.comments[number]
+	i := slices.IndexFunc(comments, func(c *gh.Comment) bool { return c.ID == commentID })
+	if i < 0 {
+		return fmt.Errorf("failed to delete comment: comment %d not found on #%d", commentID, number)
+	}
+	f.comments[number] = slices.Delete(comments, i, i+1)
+	return nil
+}
+
+// fail returns and clears the failure injected for method, if any.
+func (f *Forge) fail(method string) error {
+	err := f.failures[method]
+	delete(f.failures, method)
+	return err
+}
+
+func (f *Forge) pull(number int) (*gh.PullRequest, error) {
+	if number < 1 || number > len(f.pulls) {
+		return nil, fmt.Errorf("pull request #%d not found", number)
+	}
+	return f.pulls[number-1], nil
+}
+
+func copyOf(pr *gh.PullRequest) *gh.PullRequest {
+	copied := *pr
+	return &copied
+}
+
+// mergeInRepo merges the head branch of pr into its base in RepoDir without
+// a working tree, and returns the new tip of the base branch.
+func (f *Forge) mergeInRepo(pr *gh.PullRequest, method gh.MergeMethod) (string, error) {
+	base, err := f.git("rev-parse", "--verify", "refs/heads/"+pr.BaseRefName)
+	if err != nil {
+		return "", err
+	}
+	head, err := f.git("rev-parse", "--verify", "refs/heads/"+pr.HeadRefName)
+	if err != nil {
+		return "", err
+	}
+
+	var tip string
+	switch method {
+	case gh.MergeMethodMerge, gh.MergeMethodSquash:
+		tree, err := f.git("merge-tree", "--write-tree", base, head)
+		if err != nil {
+			return "", notMergeable("merge conflict: %v", err)
+		}
+		message := fmt.Sprintf("%s (#%d)", pr.Title, pr.Number)
+		parents := []string{"-p", base}
+		if method == gh.MergeMethodMerge {
+			message = fmt.Sprintf("Merge pull request #%d from %s\n\n%s", pr.Number, pr.HeadRefName, pr.Title)
+			parents = append(parents, "-p", head)
+		}
+		args := append([]string{"commit-tree", tree}, parents...)
+		if tip, err = f.git(append(args, "-m", message)...); err != nil {
+			return "", err
+		}
+	case gh.MergeMethodRebase:
+		if tip, err = f.rebaseInRepo(base, head); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unknown merge method %q", method)
+	}
+
+	if _, err := f.git("update-ref", "refs/heads/"+pr.BaseRefName, tip, base); err != nil {
+		return "", err
+	}
+	return tip, nil
+}
+
+// rebaseInRepo replays base..head onto base in a temporary worktree and
+// returns the resulting tip.
+func (f *Forge) rebaseInRepo(base, head string) (string, error) {
+	dir, err := os.MkdirTemp("", "ghfake-rebase-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	worktree := filepath.Join(dir, "worktree")
+
+	if _, err := f.git("worktree", "add", "--detach", worktree, base); err != nil {
+		return "", err
+	}
+	defer f.git("worktree", "remove", "--force", worktree)
+
+	commits, err := f.git("rev-list", "--reverse", "--no-merges", base+".."+head)
+	if err != nil {
+		return "", err
+	}
+	if commits != "" {
+		args := append([]string{"-C", worktree, "cherry-pick", "--allow-empty"}, strings.Fields(commits)...)
+		if _, err := f.run(args...); err != nil {
+			return "", notMergeable("conflict while rebasing: %v", err)
+		}
+	}
+	return f.run("-C", worktree, "rev-parse", "HEAD")
+}
+
+func (f *Forge) git(args ...string) (string, error) {
+	return f.run(append([]string{"--git-dir", f.RepoDir}, args...)...)
+}
+
+func (f *Forge) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=ghfake", "GIT_AUTHOR_EMAIL=ghfake@forge.example",
+		"GIT_COMMITTER_NAME=ghfake", "GIT_COMMITTER_EMAIL=ghfake@forge.example",
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git %s: %w: %s", args, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
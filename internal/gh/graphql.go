@@ -0,0 +1,75 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultGraphQLURL is resolved against the REST base URL, which gives
+// https://api.github.com/graphql for github.com.
+const defaultGraphQLURL = "graphql"
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphQLErrors is the errors list of a GraphQL response, which GitHub
+// sends with 200 OK.
+type graphQLErrors []graphQLError
+
+func (e graphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "GraphQL request failed: " + strings.Join(messages, "; ")
+}
+
+// HTTPStatus returns the status the REST API answers the first error's type
+// with, see StatusCode. A mutation GitHub refuses is UNPROCESSABLE.
+func (e graphQLErrors) HTTPStatus() int {
+	switch e[0].Type {
+	case "UNPROCESSABLE":
+		return http.StatusUnprocessableEntity
+	case "FORBIDDEN":
+		return http.StatusForbidden
+	case "NOT_FOUND":
+		return http.StatusNotFound
+	}
+	return http.StatusOK
+}
+
+type graphQLResponse[T any] struct {
+	Data   T              `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// graphQL runs a GraphQL query or mutation and decodes its data into out.
+func graphQL[T any](ctx context.Context, c *Client, query string, variables map[string]any, out *T) error {
+	req, err := c.api.NewRequest("POST", c.graphqlURL, &graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build GraphQL request: %w", err)
+	}
+
+	var resp graphQLResponse[T]
+	if _, err := c.api.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("GraphQL request failed: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return graphQLErrors(resp.Errors)
+	}
+
+	*out = resp.Data
+	return nil
+}
@@ -0,0 +1,52 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v62/github"
+)
+
+// MergeMethod is how a pull request is merged into its base.
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "merge"
+	MergeMethodSquash MergeMethod = "squash"
+	MergeMethodRebase MergeMethod = "rebase"
+)
+
+// AllowedMergeMethods returns the merge methods enabled in the repository's
+// settings, in the order zip prefers them.
+func (c *Client) AllowedMergeMethods(ctx context.Context) ([]MergeMethod, error) {
+	repo, _, err := c.api.Repositories.Get(ctx, c.owner, c.repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository settings: %w", err)
+	}
+
+	var methods []MergeMethod
+	if repo.GetAllowMergeCommit() {
+		methods = append(methods, MergeMethodMerge)
+	}
+	if repo.GetAllowSquashMerge() {
+		methods = append(methods, MergeMethodSquash)
+	}
+	if repo.GetAllowRebaseMerge() {
+		methods = append(methods, MergeMethodRebase)
+	}
+	return methods, nil
+}
+
+// MergePullRequest merges a pull request with the given method and returns
+// it in its merged state. GitHub answers 409 if headSHA is set and the head
+// has moved.
+func (c *Client) MergePullRequest(ctx context.Context, number int, method MergeMethod, headSHA string) (*PullRequest, error) {
+	_, _, err := c.api.PullRequests.Merge(ctx, c.owner, c.repo, number, "", &github.PullRequestOptions{
+		MergeMethod: string(method),
+		SHA:         headSHA,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s pull request #%d: %w", method, number, err)
+	}
+
+	return c.GetPullRequest(ctx, number)
+}
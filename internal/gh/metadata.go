@@ -0,0 +1,255 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v62/github"
+	"strings"
+)
+
+// PRMetadata holds the extra details applied to a pull request after creation.
+type PRMetadata struct {
+	Labels    []string
+	Assignees []string
+	// Reviewers may be individual logins or teams in "org/team" form.
+	Reviewers []string
+	Milestone string
+}
+
+// IsEmpty reports whether there is nothing to apply.
+func (m PRMetadata) IsEmpty() bool {
+	return len(m.Labels) == 0 && len(m.Assignees) == 0 && len(m.Reviewers) == 0 && m.Milestone == ""
+}
+
+// ListLabels returns the names of all labels defined on the repository.
+func (c *Client) ListLabels(ctx context.Context) ([]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+
+	var names []string
+	for {
+		labels, resp, err := c.api.Issues.ListLabels(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list labels: %w", err)
+		}
+
+		for _, label := range labels {
+			names = append(names, label.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return names, nil
+}
+
+// ListCollaborators returns the logins of everyone with access to the repository.
+func (c *Client) ListCollaborators(ctx context.Context) ([]string, error) {
+	opts := &github.ListCollaboratorsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var logins []string
+	for {
+		users, resp, err := c.api.Repositories.ListCollaborators(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list collaborators: %w", err)
+		}
+
+		for _, user := range users {
+			logins = append(logins, user.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+// ValidateMetadata checks labels against the repository's labels and
+// assignees/reviewers against its collaborators. Team reviewers must belong
+// to the repository owner and are otherwise left to GitHub to validate.
+func (c *Client) ValidateMetadata(ctx context.Context, meta PRMetadata) error {
+	var problems []string
+
+	if len(meta.Labels) > 0 {
+		labels, err := c.ListLabels(ctx)
+		if err != nil {
+			return err
+		}
+		if unknown := missingFold(meta.Labels, labels); len(unknown) > 0 {
+			problems = append(problems, fmt.Sprintf("unknown labels: %s", strings.Join(unknown, ", ")))
+		}
+	}
+
+	users, _, err := c.splitReviewers(meta.Reviewers)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	users = append(users, meta.Assignees...)
+	if len(users) > 0 {
+		collaborators, err := c.ListCollaborators(ctx)
+		if err != nil {
+			return err
+		}
+		if unknown := missingFold(users, collaborators); len(unknown) > 0 {
+			problems = append(problems, fmt.Sprintf("not collaborators on %s/%s: %s", c.owner, c.repo, strings.Join(unknown, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid pull request details: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// AddLabels adds labels to a pull request.
+func (c *Client) AddLabels(ctx context.Context, number int, labels []string) error {
+	_, _, err := c.api.Issues.AddLabelsToIssue(ctx, c.owner, c.repo, number, labels)
+	if err != nil {
+		return fmt.Errorf("failed to add labels: %w", err)
+	}
+	return nil
+}
+
+// AddAssignees assigns users to a pull request.
+func (c *Client) AddAssignees(ctx context.Context, number int, assignees []string) error {
+	_, _, err := c.api.Issues.AddAssignees(ctx, c.owner, c.repo, number, assignees)
+	if err != nil {
+		return fmt.Errorf("failed to add assignees: %w", err)
+	}
+	return nil
+}
+
+// SetMilestone sets the milestone of a pull request by the milestone's title.
+func (c *Client) SetMilestone(ctx context.Context, number int, title string) error {
+	milestone, err := c.findMilestone(ctx, title)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.api.Issues.Edit(ctx, c.owner, c.repo, number, &github.IssueRequest{
+		Milestone: milestone.Number,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set milestone: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) findMilestone(ctx context.Context, title string) (*github.Milestone, error) {
+	opts := &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		milestones, resp, err := c.api.Issues.ListMilestones(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list milestones: %w", err)
+		}
+
+		for _, milestone := range milestones {
+			if strings.EqualFold(milestone.GetTitle(), title) {
+				return milestone, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return nil, fmt.Errorf("milestone %q not found", title)
+}
+
+// ApplyMetadata applies labels, assignees, reviewers and milestone to a pull request.
+func (c *Client) ApplyMetadata(ctx context.Context, number int, meta PRMetadata) error {
+	if len(meta.Labels) > 0 {
+		if err := c.AddLabels(ctx, number, meta.Labels); err != nil {
+			return err
+		}
+	}
+	if len(meta.Assignees) > 0 {
+		if err := c.AddAssignees(ctx, number, meta.Assignees); err != nil {
+			return err
+		}
+	}
+	if len(meta.Reviewers) > 0 {
+		if _, err := c.RequestReviewers(ctx, number, meta.Reviewers); err != nil {
+			return err
+		}
+	}
+	if meta.Milestone != "" {
+		if err := c.SetMilestone(ctx, number, meta.Milestone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyMetadataToStack validates the metadata once and applies it to every
+// pull request in a stack.
+func (c *Client) ApplyMetadataToStack(ctx context.Context, numbers []int, meta PRMetadata) error {
+	if meta.IsEmpty() {
+		return nil
+	}
+	if err := c.ValidateMetadata(ctx, meta); err != nil {
+		return err
+	}
+	for _, number := range numbers {
+		if err := c.ApplyMetadata(ctx, number, meta); err != nil {
+			return fmt.Errorf("pull request #%d: %w", number, err)
+		}
+	}
+	return nil
+}
+
+// splitReviewers separates individual logins from "org/team" team reviewers,
+// returning team slugs for the latter. GitHub looks slugs up in the
+// repository owner's organization, so teams of any other organization are
+// rejected rather than requesting the owner's team of the same name.
+func (c *Client) splitReviewers(reviewers []string) (users []string, teams []string, err error) {
+	var foreign []string
+	for _, reviewer := range reviewers {
+		reviewer = strings.TrimPrefix(strings.TrimSpace(reviewer), "@")
+		if reviewer == "" {
+			continue
+		}
+		if org, team, ok := strings.Cut(reviewer, "/"); ok {
+			if !strings.EqualFold(org, c.owner) {
+				foreign = append(foreign, reviewer)
+				continue
+			}
+			teams = append(teams, team)
+			continue
+		}
+		users = append(users, reviewer)
+	}
+	if len(foreign) > 0 {
+		return users, teams, fmt.Errorf("teams outside of %s can't review its pull requests: %s", c.owner, strings.Join(foreign, ", "))
+	}
+	return users, teams, nil
+}
+
+// missingFold returns the entries of want not present in have, ignoring case.
+func missingFold(want, have []string) []string {
+	known := make(map[string]bool, len(have))
+	for _, h := range have {
+		known[strings.ToLower(h)] = true
+	}
+
+	var missing []string
+	for _, w := range want {
+		if !known[strings.ToLower(w)] {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
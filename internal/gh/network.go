@@ -0,0 +1,68 @@
+package gh
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/v62/github"
+)
+
+// IsNetworkError reports whether err means GitHub couldn't be reached at all
+// (no connection, DNS failure, timeout) as opposed to GitHub rejecting the
+// request. Such operations can be queued and replayed later.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		var netErr net.Error
+		return errors.As(urlErr.Err, &netErr) || errors.Is(urlErr.Err, net.ErrClosed)
+	}
+	return false
+}
+
+// StatusCode returns the HTTP status of an error response from a forge API.
+// Other forges' errors report it through an HTTPStatus method.
+func StatusCode(err error) (int, bool) {
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		return errResp.Response.StatusCode, true
+	}
+	var statusErr interface{ HTTPStatus() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.HTTPStatus(), true
+	}
+	return 0, false
+}
+
+// IsMergeRefused reports whether err means the forge declined to merge a
+// pull request, e.g. because it isn't mergeable or its head moved, as
+// opposed to the request failing for network or authentication reasons.
+func IsMergeRefused(err error) bool {
+	status, ok := StatusCode(err)
+	if !ok {
+		return false
+	}
+	switch status {
+	case http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusConflict, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
@@ -19,6 +19,23 @@ type PullRequest struct {
 	Title       string
 	Body        string
 	MergeCommit string
+	// ReviewDecision is "approved", "changes_requested", "review_required" or
+	// empty when the repo doesn't require reviews.
+	ReviewDecision string
+	// Approvals counts reviewers whose latest review approves.
+	Approvals int
+	// HeadSHA is the commit the head branch points at.
+	HeadSHA string
+	// CheckStatus is the rolled-up CI state of the head commit, e.g.
+	// "success", "failure" or "pending".
+	CheckStatus string
+	// MergeableState is GitHub's mergeable_state: "clean", "blocked",
+	// "behind", "dirty", "unstable", "draft", "has_hooks", or "unknown"
+	// (or empty) while it is still being computed.
+	MergeableState string
+	// AutoMerge is set when the pull request will be merged by the forge
+	// once it can be, through auto-merge or a merge queue.
+	AutoMerge bool
 }
 
 // HeadBranchName returns the name of the head branch, trimming any "refs/heads/" prefix.
@@ -39,6 +56,28 @@ func (p *PullRequest) GetMergeCommit() string {
 	return ""
 }
 
+// MergeBlocker explains why the pull request can't be merged, or returns ""
+// if nothing is known to prevent it.
+func (p *PullRequest) MergeBlocker() string {
+	switch {
+	case p.State != "open":
+		return fmt.Sprintf("is %s", p.State)
+	case p.IsDraft || p.MergeableState == "draft":
+		return "is a draft"
+	case p.MergeableState == "dirty":
+		return "has merge conflicts"
+	case p.MergeableState == "blocked":
+		return "is blocked by failing required checks or missing approvals"
+	}
+	return ""
+}
+
+// IsMergeableStateKnown reports whether the forge has finished computing
+// whether the pull request can be merged.
+func (p *PullRequest) IsMergeableStateKnown() bool {
+	return p.MergeableState != "" && p.MergeableState != "unknown"
+}
+
 // GetPullRequest retrieves a specific pull request by its number.
 func (c *Client) GetPullRequest(ctx context.Context, number int) (*PullRequest, error) {
 	pr, _, err := c.api.PullRequests.Get(ctx, c.owner, c.repo, number)
@@ -66,23 +105,35 @@ func (c *Client) GetPullRequests(ctx context.Context, input GetPullRequestsInput
 		Base:      input.Base,
 		Sort:      input.Sort,
 		Direction: input.Dir,
-	}
-
-	prs, _, err := c.api.PullRequests.List(ctx, c.owner, c.repo, opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
 	}
 
 	var result []*PullRequest
-	for _, pr := range prs {
-		result = append(result, convertToPullRequest(pr))
+	for {
+		prs, resp, err := c.api.PullRequests.List(ctx, c.owner, c.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+
+		for _, pr := range prs {
+			result = append(result, convertToPullRequest(pr))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
 
 	return result, nil
 }
 
-// CreatePullRequest creates a new pull request.
+// CreatePullRequest creates a new pull request. On a fork, head is the
+// branch name on the fork.
 func (c *Client) CreatePullRequest(ctx context.Context, title, body, head, base string, draft bool) (*PullRequest, error) {
+	head = c.headRef(head)
 	newPr := &github.NewPullRequest{
 		Title: &title,
 		Body:  &body,
@@ -115,39 +166,31 @@ func (c *Client) UpdatePullRequest(ctx context.Context, number int, title, body
 	return convertToPullRequest(pr), nil
 }
 
-// RequestReviewers requests reviewers for a pull request.
-func (c *Client) RequestReviewers(ctx context.Context, number int, reviewers []string) (*PullRequest, error) {
-	pr, _, err := c.api.PullRequests.RequestReviewers(ctx, c.owner, c.repo, number, github.ReviewersRequest{
-		Reviewers: reviewers,
+// RetargetPullRequest changes the base branch of a pull request.
+func (c *Client) RetargetPullRequest(ctx context.Context, number int, base string) (*PullRequest, error) {
+	pr, _, err := c.api.PullRequests.Edit(ctx, c.owner, c.repo, number, &github.PullRequest{
+		Base: &github.PullRequestBranch{Ref: &base},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to request reviews: %w", err)
+		return nil, fmt.Errorf("failed to change base of pull request to %s: %w", base, err)
 	}
 
 	return convertToPullRequest(pr), nil
 }
 
-// ConvertPullRequestToDraft converts a pull request to a draft.
-func (c *Client) ConvertPullRequestToDraft(ctx context.Context, number int) (*PullRequest, error) {
-	pr, _, err := c.api.PullRequests.Edit(ctx, c.owner, c.repo, number, &github.PullRequest{
-		Draft: github.Bool(true),
-	})
-
+// RequestReviewers requests reviewers for a pull request. Entries in
+// "org/team" form are requested as team reviewers.
+func (c *Client) RequestReviewers(ctx context.Context, number int, reviewers []string) (*PullRequest, error) {
+	users, teams, err := c.splitReviewers(reviewers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert pull request to a draft: %w", err)
+		return nil, fmt.Errorf("failed to request reviews: %w", err)
 	}
-
-	return convertToPullRequest(pr), nil
-}
-
-// MarkPullRequestReadyForReview marks a pull request as ready for review.
-func (c *Client) MarkPullRequestReadyForReview(ctx context.Context, number int) (*PullRequest, error) {
-	pr, _, err := c.api.PullRequests.Edit(ctx, c.owner, c.repo, number, &github.PullRequest{
-		Draft: github.Bool(false),
+	pr, _, err := c.api.PullRequests.RequestReviewers(ctx, c.owner, c.repo, number, github.ReviewersRequest{
+		Reviewers:     users,
+		TeamReviewers: teams,
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to mark pull request as ready for review: %w", err)
+		return nil, fmt.Errorf("failed to request reviews: %w", err)
 	}
 
 	return convertToPullRequest(pr), nil
@@ -157,7 +200,7 @@ func (c *Client) MarkPullRequestReadyForReview(ctx context.Context, number int)
 func (c *Client) IsBranchMerged(ctx context.Context, branchName string) (bool, error) {
 	opts := &github.PullRequestListOptions{
 		State: "closed",
-		Head:  c.owner + ":" + branchName,
+		Head:  c.headOwner + ":" + branchName,
 	}
 
 	prs, _, err := c.api.PullRequests.List(ctx, c.owner, c.repo, opts)
@@ -176,16 +219,25 @@ func (c *Client) IsBranchMerged(ctx context.Context, branchName string) (bool, e
 
 // convertToPullRequest converts a GitHub pull request to a Stacked pull request.
 func convertToPullRequest(pr *github.PullRequest) *PullRequest {
+	state := pr.GetState()
+	// REST reports merged pull requests as closed; match the GraphQL state.
+	if pr.GetMerged() || pr.MergedAt != nil {
+		state = "merged"
+	}
+
 	return &PullRequest{
-		ID:          pr.GetNodeID(),
-		Number:      pr.GetNumber(),
-		HeadRefName: pr.GetHead().GetRef(),
-		BaseRefName: pr.GetBase().GetRef(),
-		IsDraft:     pr.GetDraft(),
-		Permalink:   pr.GetHTMLURL(),
-		State:       pr.GetState(),
-		Title:       pr.GetTitle(),
-		Body:        pr.GetBody(),
-		MergeCommit: pr.GetMergeCommitSHA(),
+		ID:             pr.GetNodeID(),
+		Number:         pr.GetNumber(),
+		HeadRefName:    pr.GetHead().GetRef(),
+		HeadSHA:        pr.GetHead().GetSHA(),
+		BaseRefName:    pr.GetBase().GetRef(),
+		IsDraft:        pr.GetDraft(),
+		Permalink:      pr.GetHTMLURL(),
+		State:          state,
+		Title:          pr.GetTitle(),
+		Body:           pr.GetBody(),
+		MergeCommit:    pr.GetMergeCommitSHA(),
+		MergeableState: pr.GetMergeableState(),
+		AutoMerge:      pr.AutoMerge != nil,
 	}
 }
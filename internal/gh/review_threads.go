@@ -0,0 +1,226 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// reviewThreadFields selects everything decoded into a reviewThreadNode.
+const reviewThreadFields = `
+fragment reviewThreadFields on PullRequestReviewThread {
+  id
+  isResolved
+  isOutdated
+  path
+  line
+  originalLine
+  diffSide
+  comments(first: 100) {
+    nodes {
+      id
+      databaseId
+      body
+      url
+      createdAt
+      author { login }
+      originalCommit { oid }
+    }
+  }
+}`
+
+const reviewThreadsQuery = `
+query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on PullRequest {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes { ...reviewThreadFields }
+      }
+    }
+  }
+}` + reviewThreadFields
+
+const resolveReviewThreadMutation = `
+mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) {
+    thread { ...reviewThreadFields }
+  }
+}` + reviewThreadFields
+
+const unresolveReviewThreadMutation = `
+mutation($id: ID!) {
+  unresolveReviewThread(input: {threadId: $id}) {
+    thread { ...reviewThreadFields }
+  }
+}` + reviewThreadFields
+
+// ReviewThread is a thread of review comments on a line of a pull request.
+type ReviewThread struct {
+	ID         string
+	Path       string
+	IsResolved bool
+	// IsOutdated is set when the line changed after the thread started.
+	IsOutdated bool
+	// Line is the line in the pull request's current head, or 0 if GitHub
+	// can't place it there anymore. OriginalLine is the line in
+	// OriginalCommit, the commit the thread started on.
+	Line           int
+	OriginalLine   int
+	OriginalCommit string
+	// Side is "RIGHT" for the new version of the file and "LEFT" for lines
+	// the pull request removes.
+	Side     string
+	Comments []ReviewComment
+}
+
+// ReviewComment is a comment in a review thread.
+type ReviewComment struct {
+	ID string
+	// DatabaseID is the REST API ID, used to reply to the comment.
+	DatabaseID int64
+	Author     string
+	Body       string
+	URL        string
+	CreatedAt  time.Time
+}
+
+type reviewThreadNode struct {
+	ID           string `json:"id"`
+	IsResolved   bool   `json:"isResolved"`
+	IsOutdated   bool   `json:"isOutdated"`
+	Path         string `json:"path"`
+	Line         *int   `json:"line"`
+	OriginalLine *int   `json:"originalLine"`
+	DiffSide     string `json:"diffSide"`
+	Comments     struct {
+		Nodes []struct {
+			ID         string    `json:"id"`
+			DatabaseID int64     `json:"databaseId"`
+			Body       string    `json:"body"`
+			URL        string    `json:"url"`
+			CreatedAt  time.Time `json:"createdAt"`
+			Author     *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			OriginalCommit *struct {
+				OID string `json:"oid"`
+			} `json:"originalCommit"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+// GetReviewThreads returns the review threads of a pull request, oldest
+// first.
+func (c *Client) GetReviewThreads(ctx context.Context, ref PullRequestRef) ([]*ReviewThread, error) {
+	id, err := c.nodeID(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var threads []*ReviewThread
+	var cursor *string
+	for {
+		var data struct {
+			Node struct {
+				ReviewThreads struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []reviewThreadNode `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"node"`
+		}
+		err := graphQL(ctx, c, reviewThreadsQuery, map[string]any{"id": id, "cursor": cursor}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get review threads of pull request #%d: %w", ref.Number, err)
+		}
+
+		for _, node := range data.Node.ReviewThreads.Nodes {
+			threads = append(threads, node.toReviewThread())
+		}
+
+		page := data.Node.ReviewThreads.PageInfo
+		if !page.HasNextPage {
+			break
+		}
+		cursor = &page.EndCursor
+	}
+	return threads, nil
+}
+
+// ReplyToReviewComment replies to a review comment, adding to its thread.
+func (c *Client) ReplyToReviewComment(ctx context.Context, number int, commentID int64, body string) (*ReviewComment, error) {
+	comment, _, err := c.api.PullRequests.CreateCommentInReplyTo(ctx, c.owner, c.repo, number, body, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reply to review comment %d: %w", commentID, err)
+	}
+	return &ReviewComment{
+		ID:         comment.GetNodeID(),
+		DatabaseID: comment.GetID(),
+		Author:     comment.GetUser().GetLogin(),
+		Body:       comment.GetBody(),
+		URL:        comment.GetHTMLURL(),
+		CreatedAt:  comment.GetCreatedAt().Time,
+	}, nil
+}
+
+// ResolveReviewThread marks a review thread as resolved.
+func (c *Client) ResolveReviewThread(ctx context.Context, threadID string) (*ReviewThread, error) {
+	var data struct {
+		ResolveReviewThread struct {
+			Thread reviewThreadNode `json:"thread"`
+		} `json:"resolveReviewThread"`
+	}
+	if err := graphQL(ctx, c, resolveReviewThreadMutation, map[string]any{"id": threadID}, &data); err != nil {
+		return nil, fmt.Errorf("failed to resolve review thread: %w", err)
+	}
+	return data.ResolveReviewThread.Thread.toReviewThread(), nil
+}
+
+// UnresolveReviewThread marks a resolved review thread as unresolved again.
+func (c *Client) UnresolveReviewThread(ctx context.Context, threadID string) (*ReviewThread, error) {
+	var data struct {
+		UnresolveReviewThread struct {
+			Thread reviewThreadNode `json:"thread"`
+		} `json:"unresolveReviewThread"`
+	}
+	if err := graphQL(ctx, c, unresolveReviewThreadMutation, map[string]any{"id": threadID}, &data); err != nil {
+		return nil, fmt.Errorf("failed to unresolve review thread: %w", err)
+	}
+	return data.UnresolveReviewThread.Thread.toReviewThread(), nil
+}
+
+func (n *reviewThreadNode) toReviewThread() *ReviewThread {
+	thread := &ReviewThread{
+		ID:         n.ID,
+		Path:       n.Path,
+		IsResolved: n.IsResolved,
+		IsOutdated: n.IsOutdated,
+		Side:       n.DiffSide,
+	}
+	if n.Line != nil {
+		thread.Line = *n.Line
+	}
+	if n.OriginalLine != nil {
+		thread.OriginalLine = *n.OriginalLine
+	}
+	for i, comment := range n.Comments.Nodes {
+		if i == 0 && comment.OriginalCommit != nil {
+			thread.OriginalCommit = comment.OriginalCommit.OID
+		}
+		rc := ReviewComment{
+			ID:         comment.ID,
+			DatabaseID: comment.DatabaseID,
+			Body:       comment.Body,
+			URL:        comment.URL,
+			CreatedAt:  comment.CreatedAt,
+		}
+		if comment.Author != nil {
+			rc.Author = comment.Author.Login
+		}
+		thread.Comments = append(thread.Comments, rc)
+	}
+	return thread
+}
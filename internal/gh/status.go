@@ -0,0 +1,160 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-github/v62/github"
+)
+
+// Check states shared by the GraphQL rollup and LoadStatus.
+const (
+	CheckStatusSuccess = "success"
+	CheckStatusFailure = "failure"
+	CheckStatusError   = "error"
+	CheckStatusPending = "pending"
+)
+
+// LoadStatus fills in the CI and review state of a pull request fetched over
+// REST, which only the GraphQL query returns directly: commit statuses and
+// check runs of the head commit are rolled up into CheckStatus, and the latest
+// review of each reviewer decides Approvals and ReviewDecision. Without the
+// branch protection rules REST can't tell "review_required" apart from no
+// reviews being needed, so ReviewDecision stays empty then.
+func (c *Client) LoadStatus(ctx context.Context, pr *PullRequest) error {
+	if pr.HeadSHA == "" {
+		return fmt.Errorf("failed to get status of pull request #%d: head commit unknown", pr.Number)
+	}
+
+	states, err := c.commitStatusStates(ctx, pr)
+	if err != nil {
+		return err
+	}
+	runStates, err := c.checkRunStates(ctx, pr)
+	if err != nil {
+		return err
+	}
+	pr.CheckStatus = rollUpCheckStates(append(states, runStates...))
+
+	latest, err := c.latestReviews(ctx, pr.Number)
+	if err != nil {
+		return err
+	}
+	pr.Approvals = 0
+	pr.ReviewDecision = ""
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			pr.Approvals++
+		case "CHANGES_REQUESTED":
+			pr.ReviewDecision = "changes_requested"
+		}
+	}
+	if pr.ReviewDecision == "" && pr.Approvals > 0 {
+		pr.ReviewDecision = "approved"
+	}
+	return nil
+}
+
+// commitStatusStates returns the state of each commit status of the pull
+// request's head commit.
+func (c *Client) commitStatusStates(ctx context.Context, pr *PullRequest) ([]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var states []string
+	for {
+		status, resp, err := c.api.Repositories.GetCombinedStatus(ctx, c.owner, c.repo, pr.HeadSHA, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit status of pull request #%d: %w", pr.Number, err)
+		}
+		for _, s := range status.Statuses {
+			states = append(states, s.GetState())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return states, nil
+}
+
+// checkRunStates returns the state of each check run of the pull request's
+// head commit.
+func (c *Client) checkRunStates(ctx context.Context, pr *PullRequest) ([]string, error) {
+	opts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var states []string
+	for {
+		runs, resp, err := c.api.Checks.ListCheckRunsForRef(ctx, c.owner, c.repo, pr.HeadSHA, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get check runs of pull request #%d: %w", pr.Number, err)
+		}
+		for _, run := range runs.CheckRuns {
+			states = append(states, checkRunState(run))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return states, nil
+}
+
+// latestReviews returns the state of each reviewer's latest approving or
+// change-requesting review, keyed by login.
+func (c *Client) latestReviews(ctx context.Context, number int) (map[string]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	latest := make(map[string]string)
+	for {
+		reviews, resp, err := c.api.PullRequests.ListReviews(ctx, c.owner, c.repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews of pull request #%d: %w", number, err)
+		}
+
+		// Reviews come oldest first, and comments don't change a verdict.
+		for _, review := range reviews {
+			switch state := review.GetState(); state {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+				latest[review.GetUser().GetLogin()] = state
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return latest, nil
+}
+
+// checkRunState maps a check run onto the commit status states.
+func checkRunState(run *github.CheckRun) string {
+	if run.GetStatus() != "completed" {
+		return CheckStatusPending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return CheckStatusSuccess
+	case "failure", "timed_out", "cancelled", "action_required", "startup_failure":
+		return CheckStatusFailure
+	}
+	return CheckStatusPending
+}
+
+// rollUpCheckStates combines states like GitHub's statusCheckRollup: any
+// failure fails, otherwise anything pending is pending.
+func rollUpCheckStates(states []string) string {
+	rollup := ""
+	for _, state := range states {
+		switch state {
+		case CheckStatusFailure, CheckStatusError:
+			return CheckStatusFailure
+		case CheckStatusPending:
+			rollup = CheckStatusPending
+		case CheckStatusSuccess:
+			if rollup == "" {
+				rollup = CheckStatusSuccess
+			}
+		}
+	}
+	return rollup
+}
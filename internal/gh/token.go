@@ -0,0 +1,182 @@
+package gh
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"zip/internal/config"
+)
+
+// DefaultHost is the host of github.com repositories.
+const DefaultHost = "github.com"
+
+// TokenProvider supplies a GitHub token from one particular source.
+type TokenProvider interface {
+	// Token returns the token, or an error explaining why none is available.
+	Token() (string, error)
+	// Name describes the source for error messages.
+	Name() string
+}
+
+// EnvTokenProvider reads GITHUB_TOKEN, then GH_TOKEN. For enterprise hosts
+// GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN are used instead, matching
+// the GitHub CLI.
+type EnvTokenProvider struct {
+	Host string
+}
+
+func (p EnvTokenProvider) Name() string {
+	return strings.Join(p.variables(), "/") + " environment variables"
+}
+
+func (p EnvTokenProvider) variables() []string {
+	if IsEnterpriseHost(p.Host) {
+		return []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	return []string{"GITHUB_TOKEN", "GH_TOKEN"}
+}
+
+func (p EnvTokenProvider) Token() (string, error) {
+	for _, name := range p.variables() {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// ConfigTokenProvider reads zip.<host>.token from git config. For
+// github.com it falls back to zip.token, which is never sent to other hosts.
+type ConfigTokenProvider struct {
+	Config *config.Config
+	Host   string
+}
+
+func (p ConfigTokenProvider) Name() string {
+	if p.Host == DefaultHost {
+		return "zip.token git config"
+	}
+	return fmt.Sprintf("zip.%s.token git config", p.Host)
+}
+
+func (p ConfigTokenProvider) Token() (string, error) {
+	token, ok := p.Config.Get(p.Host + ".token")
+	if !ok && p.Host == DefaultHost {
+		token, ok = p.Config.Get("token")
+	}
+	if ok && strings.TrimSpace(token) != "" {
+		return strings.TrimSpace(token), nil
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// GitCredentialTokenProvider asks git's credential helpers for the password
+// stored for the host, as `git credential fill` would.
+type GitCredentialTokenProvider struct {
+	Host string
+	// Dir is where git runs, so repo-local credential config applies.
+	Dir string
+}
+
+func (p GitCredentialTokenProvider) Name() string {
+	return fmt.Sprintf("git credential helper for %s", p.Host)
+}
+
+func (p GitCredentialTokenProvider) Token() (string, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Dir = p.Dir
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", p.Host))
+	// Never fall back to prompting for a username/password.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("no credentials stored")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok && password != "" {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("no password in stored credentials")
+}
+
+// GHCLITokenProvider runs `gh auth token` for the host.
+type GHCLITokenProvider struct {
+	Host string
+}
+
+func (p GHCLITokenProvider) Name() string { return "gh auth token" }
+
+func (p GHCLITokenProvider) Token() (string, error) {
+	ghPath, err := exec.LookPath("gh")
+	if err != nil {
+		return "", fmt.Errorf("GitHub CLI not found")
+	}
+
+	args := []string{"auth", "token"}
+	if p.Host != "" {
+		args = append(args, "--hostname", p.Host)
+	}
+	out, err := exec.Command(ghPath, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("not logged in")
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("empty token")
+	}
+	return token, nil
+}
+
+// StaticTokenProvider always returns the same token.
+type StaticTokenProvider string
+
+func (p StaticTokenProvider) Name() string { return "static token" }
+
+func (p StaticTokenProvider) Token() (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("empty token")
+	}
+	return string(p), nil
+}
+
+// TokenChain tries each provider in order and returns the first token found.
+type TokenChain struct {
+	// Forge names the kind of token in the error when none is found.
+	Forge     string
+	Providers []TokenProvider
+}
+
+func (c TokenChain) Name() string { return "token chain" }
+
+func (c TokenChain) Token() (string, error) {
+	var tried []string
+	for _, provider := range c.Providers {
+		token, err := provider.Token()
+		if err == nil {
+			return token, nil
+		}
+		tried = append(tried, fmt.Sprintf("  - %s: %s", provider.Name(), err))
+	}
+	return "", fmt.Errorf("no %s token found, tried:\n%s", c.Forge, strings.Join(tried, "\n"))
+}
+
+// DefaultTokenChain returns the standard lookup order: environment, zip
+// config, git credential helpers and finally the GitHub CLI.
+func DefaultTokenChain(cfg *config.Config, host, dir string) TokenChain {
+	if host == "" {
+		host = DefaultHost
+	}
+	return TokenChain{Forge: "GitHub", Providers: []TokenProvider{
+		EnvTokenProvider{Host: host},
+		ConfigTokenProvider{Config: cfg, Host: host},
+		GitCredentialTokenProvider{Host: host, Dir: dir},
+		GHCLITokenProvider{Host: host},
+	}}
+}
@@ -0,0 +1,212 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxRetries = 3
+	// maxRateLimitWait caps how long a request waits for a rate limit to
+	// reset before the error is surfaced to the caller instead.
+	maxRateLimitWait = 2 * time.Minute
+	baseBackoff      = 500 * time.Millisecond
+	maxBackoff       = 30 * time.Second
+)
+
+// retryTransport retries requests that failed for transient reasons. Rate
+// limited requests are retried once the limit resets, since GitHub rejects
+// them without acting on them. Network errors and 5xx responses are only
+// retried for idempotent methods.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, maxRetries int) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		sleep:      sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if resp != nil {
+			logRateLimit(req, resp)
+		}
+
+		wait, retry := t.retryAfter(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		next, rewindErr := rewind(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		logrus.WithFields(logrus.Fields{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait,
+		}).Debug("retrying GitHub request")
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// retryAfter decides whether to retry and how long to wait first.
+func (t *retryTransport) retryAfter(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.maxRetries {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return backoff(attempt), isIdempotent(req.Method)
+	}
+
+	if wait, limited := rateLimitWait(resp); limited {
+		return wait, wait <= maxRateLimitWait
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return backoff(attempt), isIdempotent(req.Method)
+	}
+	return 0, false
+}
+
+// rateLimitWait reports whether resp is a primary or secondary rate limit
+// response and how long GitHub asks us to wait.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// Secondary rate limits send Retry-After in seconds.
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds)*time.Second + jitter(time.Second), true
+		}
+	}
+
+	// Primary rate limits report the reset time as a unix timestamp.
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait := time.Until(time.Unix(reset, 0))
+			return max(wait, 0) + jitter(time.Second), true
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+func logRateLimit(req *http.Request, resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		"method":    req.Method,
+		"path":      req.URL.Path,
+		"status":    resp.StatusCode,
+		"remaining": remaining,
+		"limit":     resp.Header.Get("X-RateLimit-Limit"),
+		"resource":  resp.Header.Get("X-RateLimit-Resource"),
+	}).Debug("GitHub rate limit")
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("request body cannot be replayed")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, nil
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns an exponential delay with full jitter.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return jitter(d)
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(d)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimitReset reports whether err was caused by an exhausted rate limit
+// and, if so, when it resets. Long running commands use it to stop cleanly
+// and tell the user when to resume.
+func RateLimitReset(err error) (time.Time, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateErr.Rate.Reset.Time, true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return time.Now().Add(abuseErr.GetRetryAfter()), true
+	}
+	return time.Time{}, false
+}
@@ -55,18 +55,29 @@ func (r *Repo) Switch(opts *SwitchOpts) (string, error) {
 	return previousBranch, err
 }
 
+// DefaultBranch returns the HEAD branch of the base remote.
 func (r *Repo) DefaultBranch() (string, error) {
-	ref, err := r.Git("symbolic-ref", "refs/remotes/origin/HEAD")
+	remote, err := r.BaseRemote()
+	if err != nil {
+		return "", err
+	}
+	branch, err := r.RemoteHead(remote)
 	if err != nil {
 		logrus.WithError(err).Debug("failed to determine remote HEAD")
 		return "", errors.New("failed to determine remote HEAD")
 	}
-	return strings.TrimPrefix(ref, "refs/remotes/origin/"), nil
+	return branch, nil
 }
 
+// BranchExists checks for a local branch, or with remote set, for the branch
+// on the remote it is pushed to.
 func (r *Repo) BranchExists(name string, remote bool) (bool, error) {
 	if remote {
-		return r.DoesRefExist(fmt.Sprintf("refs/remotes/origin/%s", name))
+		remoteName, err := r.remoteForBranch(name)
+		if err != nil {
+			return false, err
+		}
+		return r.DoesRefExist(fmt.Sprintf("refs/remotes/%s/%s", remoteName, name))
 	}
 	return r.DoesRefExist(fmt.Sprintf("refs/heads/%s", name))
 }
@@ -92,7 +103,7 @@ func (r *Repo) GetLastCommit() (string, error) {
 		Args: []string{"rev-parse", "HEAD"},
 	})
 	if err != nil {
-		return "", errors.WrapIff(err, "failed to get last commit: %w", err)
+		return "", errors.Wrap(err, "failed to get last commit")
 	}
 	if res.ExitCode != 0 {
 		return "", errors.Errorf("failed to get last commit: %s", res.Stderr)
@@ -114,8 +125,13 @@ func (r *Repo) Pull(branchName string) error {
 		defer r.Switch(&SwitchOpts{Name: currentBranch}) // Switch back to the original branch
 	}
 
+	remote, err := r.remoteForBranch(branchName)
+	if err != nil {
+		return err
+	}
+
 	output, err := r.Run(&RunOpts{
-		Args: []string{"pull", "--ff-only", "origin", branchName},
+		Args: []string{"pull", "--ff-only", remote, branchName},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to pull changes for branch %s: %w", branchName, err)
@@ -130,8 +146,12 @@ func (r *Repo) Pull(branchName string) error {
 
 // PushNewBranch pushes a new branch to the remote for the first time
 func (r *Repo) PushNewBranch(branchName string) error {
-	args := []string{"push", "--set-upstream", "origin", branchName}
-	_, err := r.Run(&RunOpts{Args: args})
+	remote, err := r.PushRemote()
+	if err != nil {
+		return err
+	}
+	args := []string{"push", "--set-upstream", remote, branchName}
+	_, err = r.Run(&RunOpts{Args: args})
 	if err != nil {
 		return fmt.Errorf("failed to push new branch %s: %w", branchName, err)
 	}
@@ -150,14 +170,51 @@ func (r *Repo) FastForwardBranch(ref string) error {
 
 // PushWithForceWithLease pushes the current branch with --force-with-lease
 func (r *Repo) PushWithForceWithLease(branchName string) error {
-	args := []string{"push", "--force-with-lease", "origin", branchName}
-	_, err := r.Run(&RunOpts{Args: args})
+	remote, err := r.PushRemote()
+	if err != nil {
+		return err
+	}
+	args := []string{"push", "--force-with-lease", remote, branchName}
+	_, err = r.Run(&RunOpts{Args: args})
 	if err != nil {
 		return fmt.Errorf("failed to push branch %s with force-with-lease: %w", branchName, err)
 	}
 	return nil
 }
 
+// FetchRemoteBranch fetches a branch from the remote it lives on and returns
+// its remote-tracking ref, e.g. "origin/main".
+func (r *Repo) FetchRemoteBranch(branchName string) (string, error) {
+	remote, err := r.remoteForBranch(branchName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.Git("fetch", remote, branchName); err != nil {
+		return "", errors.WrapIff(err, "failed to fetch %s from %s", branchName, remote)
+	}
+	return remote + "/" + branchName, nil
+}
+
+// PushCommit pushes a commit to a branch on the push remote, overwriting it
+// only if it still points at expected. An empty expected requires the remote
+// branch not to exist yet.
+func (r *Repo) PushCommit(commit, remoteBranch, expected string) error {
+	remote, err := r.PushRemote()
+	if err != nil {
+		return err
+	}
+	args := []string{
+		"push", remote,
+		fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", remoteBranch, expected),
+		fmt.Sprintf("%s:refs/heads/%s", commit, remoteBranch),
+	}
+	_, err = r.Run(&RunOpts{Args: args, ExitError: true})
+	if err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", commit, remoteBranch, err)
+	}
+	return nil
+}
+
 // IsBranchMerged checks if a branch is merged into the default branch
 func (r *Repo) IsBranchMerged(branchName string) (bool, error) {
 	defaultBranch, err := r.DefaultBranch()
@@ -220,8 +277,12 @@ func (r *Repo) DeleteBranch(branchName string) error {
 
 	// If the branch exists on the remote, delete it
 	if remoteBranchExists {
+		remote, err := r.remoteForBranch(branchName)
+		if err != nil {
+			return err
+		}
 		_, err = r.Run(&RunOpts{
-			Args: []string{"push", "origin", "--delete", branchName},
+			Args: []string{"push", remote, "--delete", branchName},
 		})
 		if err != nil {
 			return fmt.Errorf("failed to delete remote branch %s: %w", branchName, err)
@@ -233,12 +294,16 @@ func (r *Repo) DeleteBranch(branchName string) error {
 
 // AV - push.go L#280
 func (r *Repo) Push(branchName, remoteCommit string) error {
-	pushArgs := []string{"push", r.GetRemoteName(), "--atomic", fmt.Sprintf("--force-with-lease=%s:%s", branchName, remoteCommit)}
+	remote, err := r.PushRemote()
+	if err != nil {
+		return err
+	}
+	pushArgs := []string{"push", remote, "--atomic", fmt.Sprintf("--force-with-lease=%s:%s", branchName, remoteCommit)}
 	res, err := r.Run(&RunOpts{
 		Args: pushArgs,
 	})
 	if err != nil {
-		return errors.WrapIff(err, "failed to push branch to GitHub: %s\n%w", branchName, err)
+		return errors.WrapIff(err, "failed to push branch to GitHub: %s", branchName)
 	}
 	if res.ExitCode != 0 {
 		return errors.Errorf("failed to push branch to GitHub: %s\n%s ", branchName, res.Stderr)
@@ -0,0 +1,162 @@
+package git
+
+import (
+	"crypto/rand"
+	"emperror.dev/errors"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ChangeIDTrailer is the commit trailer identifying a change across rebases
+// and amends in single-branch mode, like Gerrit's Change-Id.
+const ChangeIDTrailer = "Zip-Change-Id"
+
+var (
+	changeIDRegex = regexp.MustCompile(`(?m)^` + ChangeIDTrailer + `:\s*(I[0-9a-f]{40})\s*$`)
+	trailerRegex  = regexp.MustCompile(`^[A-Za-z0-9-]+:\s`)
+)
+
+// ChangeID returns the Zip-Change-Id of a commit message, or "" if it has
+// none. If there are several, the last one wins, as for git trailers.
+func ChangeID(message string) string {
+	matches := changeIDRegex.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// WithoutChangeID removes Zip-Change-Id trailers from a commit message, e.g.
+// to use the message as a pull request description.
+func WithoutChangeID(message string) string {
+	return strings.TrimSpace(changeIDRegex.ReplaceAllString(message, ""))
+}
+
+// NewChangeID returns a new random change ID.
+func NewChangeID() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "failed to generate change ID")
+	}
+	return "I" + hex.EncodeToString(buf), nil
+}
+
+// WithChangeID adds a Zip-Change-Id trailer to a commit message, joining an
+// existing trailer block if the message ends with one.
+func WithChangeID(message, changeID string) string {
+	message = strings.TrimRight(message, "\n")
+	trailer := ChangeIDTrailer + ": " + changeID
+
+	paragraphs := strings.Split(message, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	isTrailerBlock := len(paragraphs) > 1 && !slices.ContainsFunc(strings.Split(last, "\n"), func(line string) bool {
+		return !trailerRegex.MatchString(line)
+	})
+	if isTrailerBlock {
+		return message + "\n" + trailer + "\n"
+	}
+	return message + "\n\n" + trailer + "\n"
+}
+
+// EnsureChangeIDs gives every commit in base..branch a Zip-Change-Id trailer,
+// rewriting the commits that lack one with commit-tree so trees, authors and
+// dates are kept. The branch is moved to the rewritten commits; its tree
+// doesn't change, so a checked out working copy is unaffected. It returns the
+// resulting commits, oldest first.
+func (r *Repo) EnsureChangeIDs(base, branch string) ([]*CommitInfo, error) {
+	commits, err := r.FetchGitLog(LogOptions{
+		RevisionRange:    []string{"--reverse", base + ".." + branch},
+		SpecificToBranch: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The log skips merges, so check for them even if nothing is rewritten.
+	merges, err := r.Git("rev-list", "--merges", base+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	if merges != "" {
+		return nil, errors.Errorf("%s contains merge commits; single-branch mode needs a linear history", branch)
+	}
+	if !slices.ContainsFunc(commits, func(c *CommitInfo) bool { return ChangeID(c.Body) == "" }) {
+		return commits, nil
+	}
+
+	oldTip, err := r.Git("rev-parse", "--verify", branch+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	parent, err := r.Git("rev-parse", "--verify", commits[0].Hash+"^")
+	if err != nil {
+		return nil, err
+	}
+
+	rewriting := false
+	for _, commit := range commits {
+		if ChangeID(commit.Body) != "" && !rewriting {
+			// Leave the untouched prefix of the branch as it is.
+			parent = commit.Hash
+			continue
+		}
+		rewriting = true
+
+		hash, err := r.rewriteCommit(commit.Hash, parent)
+		if err != nil {
+			return nil, err
+		}
+		parent = hash
+	}
+
+	if _, err := r.Git("update-ref", "-m", "zip: add "+ChangeIDTrailer+" trailers", "refs/heads/"+branch, parent, oldTip); err != nil {
+		return nil, errors.WrapIff(err, "failed to update %s", branch)
+	}
+
+	return r.FetchGitLog(LogOptions{
+		RevisionRange:    []string{"--reverse", base + ".." + branch},
+		SpecificToBranch: true,
+	})
+}
+
+// rewriteCommit recreates a commit on top of parent, adding a change ID to
+// its message if it has none.
+func (r *Repo) rewriteCommit(hash, parent string) (string, error) {
+	out, err := r.Run(&RunOpts{
+		Args:      []string{"show", "-s", "--format=%an%x00%ae%x00%aI%x00%T%x00%B", hash},
+		ExitError: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	fields := strings.SplitN(string(out.Stdout), "\x00", 5)
+	if len(fields) != 5 {
+		return "", errors.Errorf("unexpected output of git show for %s", hash)
+	}
+	message := fields[4]
+	if ChangeID(message) == "" {
+		changeID, err := NewChangeID()
+		if err != nil {
+			return "", err
+		}
+		message = WithChangeID(message, changeID)
+	}
+
+	res, err := r.Run(&RunOpts{
+		Args: []string{"commit-tree", fields[3], "-p", parent, "-F", "-"},
+		Env: []string{
+			"GIT_AUTHOR_NAME=" + fields[0],
+			"GIT_AUTHOR_EMAIL=" + fields[1],
+			"GIT_AUTHOR_DATE=" + fields[2],
+		},
+		Stdin:     strings.NewReader(message),
+		ExitError: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite commit %s: %w", hash, err)
+	}
+	return strings.TrimSpace(string(res.Stdout)), nil
+}
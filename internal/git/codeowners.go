@@ -0,0 +1,174 @@
+package git
+
+import (
+	"bufio"
+	"emperror.dev/errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeOwnersLocations are searched in the same order GitHub uses; the first
+// file found wins.
+var codeOwnersLocations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+type codeOwnersRule struct {
+	pattern string
+	regex   *regexp.Regexp
+	owners  []string
+}
+
+// CodeOwners is a parsed CODEOWNERS file.
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+// ReadCodeOwners finds and parses the repository's CODEOWNERS file. It returns
+// nil without an error if the repository has none.
+func (r *Repo) ReadCodeOwners() (*CodeOwners, error) {
+	for _, location := range codeOwnersLocations {
+		contents, err := os.ReadFile(filepath.Join(r.repoDir, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", location)
+		}
+		return ParseCodeOwners(string(contents))
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses the contents of a CODEOWNERS file.
+func ParseCodeOwners(contents string) (*CodeOwners, error) {
+	co := &CodeOwners{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		fields := strings.Fields(line)
+		regex, err := compileCodeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, errors.WrapIff(err, "invalid CODEOWNERS pattern %q", fields[0])
+		}
+		co.rules = append(co.rules, codeOwnersRule{
+			pattern: fields[0],
+			regex:   regex,
+			owners:  fields[1:],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to parse CODEOWNERS")
+	}
+	return co, nil
+}
+
+// Owners returns the owners of a single path. As on GitHub, the last matching
+// rule takes precedence, and a matching rule without owners clears ownership.
+func (co *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].regex.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Reviewers returns the reviewers owning any of the given paths, in first-seen
+// order. Owners are returned without the leading "@" ("user" or "org/team");
+// email owners are skipped because reviews can't be requested by email.
+func (co *CodeOwners) Reviewers(paths []string) []string {
+	seen := make(map[string]bool)
+	var reviewers []string
+	for _, path := range paths {
+		for _, owner := range co.Owners(path) {
+			if !strings.HasPrefix(owner, "@") {
+				continue
+			}
+			owner = strings.TrimPrefix(owner, "@")
+			key := strings.ToLower(owner)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			reviewers = append(reviewers, owner)
+		}
+	}
+	return reviewers
+}
+
+// compileCodeOwnersPattern turns a gitignore-style CODEOWNERS pattern into a
+// regular expression matched against slash separated repository paths.
+func compileCodeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// Patterns with a slash anywhere but the end are relative to the root.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories.
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	lastSegment := pattern[strings.LastIndex(pattern, "/")+1:]
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*")
+	case !strings.Contains(lastSegment, "*"):
+		// A pattern naming a directory also matches everything inside it,
+		// while "docs/*" only matches the direct children of docs.
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// ChangedFiles returns the files that differ between two revisions.
+func (r *Repo) ChangedFiles(base, tip string) ([]string, error) {
+	diff, err := r.CalculateDiff(DiffConfig{
+		Revisions: []string{base, tip},
+		NameOnly:  true,
+	})
+	if err != nil {
+		return nil, errors.WrapIff(err, "failed to list files changed between %s and %s", base, tip)
+	}
+	return diff.Files(), nil
+}
@@ -1,11 +1,16 @@
 package git
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type DiffConfig struct {
 	Revisions []string
 	IsQuiet   bool
 	UseColor  bool
+	Stat      bool
+	NameOnly  bool
 	FilePaths []string
 }
 
@@ -14,6 +19,17 @@ type DiffResult struct {
 	Content        string
 }
 
+// Files returns the file names listed in a NameOnly diff.
+func (d *DiffResult) Files() []string {
+	var files []string
+	for _, line := range strings.Split(d.Content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
 func (r *Repo) CalculateDiff(config DiffConfig) (*DiffResult, error) {
 	args := buildDiffArgs(config)
 	output, err := r.Run(&RunOpts{
@@ -35,6 +51,12 @@ func buildDiffArgs(config DiffConfig) []string {
 	if config.UseColor {
 		args = append(args, "--color=always")
 	}
+	if config.Stat {
+		args = append(args, "--stat")
+	}
+	if config.NameOnly {
+		args = append(args, "--name-only")
+	}
 
 	args = append(args, config.Revisions...)
 	args = append(args, "--")
@@ -5,6 +5,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/sirupsen/logrus"
 	"path/filepath"
+	"zip/internal/config"
 )
 
 var ErrRemoteNotFound = errors.Sentinel("this repository doesn't have a remote origin")
@@ -16,6 +17,13 @@ type Repo struct {
 	gitDir  string
 	gitRepo *git.Repository
 	log     logrus.FieldLogger
+	cfg     *config.Config
+	hook    RunHook
+
+	// remotes and remoteHeads are resolved lazily and kept for the lifetime
+	// of the Repo, since remoteForBranch consults them for every branch.
+	remotes     []Remote
+	remoteHeads map[string]remoteHead
 }
 
 func OpenRepo(repoDir, gitDir string) (*Repo, error) {
@@ -26,19 +34,52 @@ func OpenRepo(repoDir, gitDir string) (*Repo, error) {
 	if err != nil {
 		return nil, errors.Errorf("failed to open git repo: %v", err)
 	}
+	cfg, err := config.Load(repoDir)
+	if err != nil {
+		return nil, err
+	}
 	r := &Repo{
 		repoDir,
 		gitDir,
 		repo,
 		logrus.WithField("repo", filepath.Base(repoDir)),
+		cfg,
+		nil,
+		nil,
+		make(map[string]remoteHead),
 	}
 	return r, nil
 }
 
+// Config returns the zip settings read from the repo's git config.
+func (r *Repo) Config() *config.Config {
+	return r.cfg
+}
+
+// GetRemoteName returns the name of the push remote, falling back to origin
+// when it can't be determined. Use PushRemote to handle the error.
 func (r *Repo) GetRemoteName() string {
+	if name, err := r.PushRemote(); err == nil {
+		return name
+	}
 	return DEFAULT_REMOTE_NAME
 }
 
+// BaseRemoteName returns the name of the base remote, falling back to the
+// push remote name. Use BaseRemote to handle the error.
+func (r *Repo) BaseRemoteName() string {
+	if name, err := r.BaseRemote(); err == nil {
+		return name
+	}
+	return r.GetRemoteName()
+}
+
+// IsForkWorkflow reports whether branches are pushed to a different remote
+// than the one pull requests target.
+func (r *Repo) IsForkWorkflow() bool {
+	return r.GetRemoteName() != r.BaseRemoteName()
+}
+
 type RevParse struct {
 	Rev              string
 	SymbolicFullName bool
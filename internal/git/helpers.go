@@ -6,13 +6,21 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 )
 
 // Git runs git with the given arguments and returns the output as a string.
 func (r *Repo) Git(args ...string) (string, error) {
+	if r.hook != nil {
+		// Go through Run so the hook sees every invocation.
+		out, err := r.Run(&RunOpts{Args: args, ExitError: true})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out.Stdout)), nil
+	}
+
 	startTime := time.Now()
 	cmd := exec.Command("git", args...)
 	cmd.Dir = r.repoDir
@@ -74,7 +82,27 @@ func (o Output) Lines() []string {
 	return strings.Split(s, "\n")
 }
 
+// RunHook wraps git invocations made through Run and Git, e.g. to record
+// them for a bug report or to replay them from one.
+type RunHook interface {
+	// Run is called for every invocation. next runs git for real; a hook
+	// may call it, or return a result without running git at all.
+	Run(opts *RunOpts, next func(*RunOpts) (*Output, error)) (*Output, error)
+}
+
+// SetRunHook installs a hook around git invocations. A nil hook removes it.
+func (r *Repo) SetRunHook(hook RunHook) {
+	r.hook = hook
+}
+
 func (r *Repo) Run(opts *RunOpts) (*Output, error) {
+	if r.hook != nil {
+		return r.hook.Run(opts, r.run)
+	}
+	return r.run(opts)
+}
+
+func (r *Repo) run(opts *RunOpts) (*Output, error) {
 	cmd := exec.Command("git", opts.Args...)
 	cmd.Dir = r.repoDir
 	r.log.Debugf("git %s", opts.Args)
@@ -109,32 +137,3 @@ func (r *Repo) Run(opts *RunOpts) (*Output, error) {
 		Stderr:    stderr.Bytes(),
 	}, nil
 }
-
-// GetPRTemplate returns the PR template for the repo
-func (r *Repo) GetPRTemplate() (string, error) {
-	// Check if the repo has a .github directory
-	if _, err := os.Stat(filepath.Join(r.repoDir, ".github")); err != nil {
-		return "", err
-	}
-
-	// Check if the repo has a .github/pull_request_template.md file
-	if _, err := os.Stat(filepath.Join(r.repoDir, ".github", "pull_request_template.md")); err != nil {
-		return "", err
-	}
-
-	// Read the file contents
-	file, err := os.Open(filepath.Join(r.repoDir, ".github", "pull_request_template.md"))
-	if err != nil {
-		return "", err
-	}
-
-	defer file.Close()
-
-	// Read the contents of the file
-	contents, err := io.ReadAll(file)
-	if err != nil {
-		return "", err
-	}
-
-	return string(contents), nil
-}
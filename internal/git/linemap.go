@@ -0,0 +1,161 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LineMapper follows lines of files from one commit to another using the
+// diff between them, e.g. to find where a review comment made on an older
+// revision points at after rebases and amends.
+type LineMapper struct {
+	files map[string]*fileDiff
+}
+
+type fileDiff struct {
+	newPath string
+	deleted bool
+	hunks   []hunk
+}
+
+type hunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+}
+
+// NewLineMapper diffs from against to, following renames. The path prefixes
+// and quoting are pinned so diff.noprefix, diff.mnemonicPrefix and
+// core.quotePath settings don't change the output parsed here.
+func (r *Repo) NewLineMapper(from, to string) (*LineMapper, error) {
+	out, err := r.Git("-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--find-renames", "--unified=0",
+		"--src-prefix=a/", "--dst-prefix=b/", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return parseLineMapper(out)
+}
+
+// HasCommit reports whether the commit is available locally.
+func (r *Repo) HasCommit(sha string) bool {
+	_, err := r.Git("cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// MapLine returns where a line of path in the old commit is in the new one.
+// The line is 0 if it was changed or removed in between, since there is
+// nothing left to point at.
+func (m *LineMapper) MapLine(path string, line int) (string, int) {
+	diff, ok := m.files[path]
+	if !ok {
+		return path, line
+	}
+	if diff.deleted {
+		return path, 0
+	}
+
+	offset := 0
+	for _, h := range diff.hunks {
+		if h.oldCount == 0 {
+			// Pure insertion after line oldStart.
+			if line <= h.oldStart {
+				break
+			}
+		} else {
+			if line < h.oldStart {
+				break
+			}
+			if line < h.oldStart+h.oldCount {
+				return diff.newPath, 0
+			}
+		}
+		offset += h.newCount - h.oldCount
+	}
+	return diff.newPath, line + offset
+}
+
+func parseLineMapper(out string) (*LineMapper, error) {
+	m := &LineMapper{files: make(map[string]*fileDiff)}
+	var current *fileDiff
+	var oldPath string
+	// remaining counts the changed lines left in the current hunk, which
+	// must not be mistaken for headers, e.g. a removed "-- " line.
+	remaining := 0
+	for _, line := range strings.Split(out, "\n") {
+		if remaining > 0 && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")) {
+			remaining--
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+			oldPath = ""
+		case strings.HasPrefix(line, "--- "):
+			oldPath = strings.TrimPrefix(diffPath(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			if oldPath == "" || oldPath == "/dev/null" {
+				// Added files have no old lines to map.
+				continue
+			}
+			newPath := diffPath(line, "+++ ")
+			current = &fileDiff{
+				newPath: strings.TrimPrefix(newPath, "b/"),
+				deleted: newPath == "/dev/null",
+			}
+			m.files[oldPath] = current
+		case strings.HasPrefix(line, "rename from "):
+			// Renames without content changes have no ---/+++ lines.
+			oldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to ") && oldPath != "":
+			current = &fileDiff{newPath: strings.TrimPrefix(line, "rename to ")}
+			m.files[oldPath] = current
+		case strings.HasPrefix(line, "@@ ") && current != nil:
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			current.hunks = append(current.hunks, h)
+			remaining = h.oldCount + h.newCount
+		}
+	}
+	return m, nil
+}
+
+// diffPath returns the path of a ---/+++ line. Git ends paths containing
+// spaces with a tab.
+func diffPath(line, prefix string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(line, prefix), "\t")
+}
+
+// parseHunkHeader parses "@@ -oldStart[,oldCount] +newStart[,newCount] @@".
+func parseHunkHeader(line string) (hunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return hunk{}, fmt.Errorf("invalid hunk header %q", line)
+	}
+	var h hunk
+	var err error
+	if h.oldStart, h.oldCount, err = parseRange(strings.TrimPrefix(fields[1], "-")); err != nil {
+		return hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	if h.newStart, h.newCount, err = parseRange(strings.TrimPrefix(fields[2], "+")); err != nil {
+		return hunk{}, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	return h, nil
+}
+
+func parseRange(s string) (int, int, error) {
+	start, count, found := strings.Cut(s, ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return n, 1, nil
+	}
+	c, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, c, nil
+}
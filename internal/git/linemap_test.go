@@ -0,0 +1,177 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	insertionDiff = `diff --git a/f.txt b/f.txt
+index 1111111..2222222 100644
+--- a/f.txt
++++ b/f.txt
+@@ -2,0 +3,2 @@ two
++new one
++new two
+`
+	deletionDiff = `diff --git a/f.txt b/f.txt
+index 1111111..2222222 100644
+--- a/f.txt
++++ b/f.txt
+@@ -3,2 +2,0 @@ one
+-three
+-four
+@@ -8 +7,3 @@ seven
+-eight
++eight
++and a half
++more
+`
+	renameDiff = `diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+diff --git a/a.go b/b.go
+similarity index 80%
+rename from a.go
+rename to b.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/b.go
+@@ -1 +1,2 @@
+-package a
++package b
++
+`
+	deletedFileDiff = `diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 1111111..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-one
+-two
+diff --git a/added.txt b/added.txt
+new file mode 100644
+index 0000000..1111111
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++one
+`
+	// Removing a "-- " line and adding a "++ x" line produce lines that look
+	// like ---/+++ headers. Git ends paths with a space in headers with a tab.
+	headerLikeDiff = "diff --git a/sig.txt b/sig.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/sig.txt\n" +
+		"+++ b/sig.txt\n" +
+		"@@ -2 +1,0 @@ one\n" +
+		"--- \n" +
+		"@@ -5,0 +5 @@ five\n" +
+		"++++ x\n" +
+		"diff --git a/with space.txt b/with space.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/with space.txt\t\n" +
+		"+++ b/with space.txt\t\n" +
+		"@@ -1,0 +2 @@ one\n" +
+		"+two\n"
+)
+
+func TestLineMapper(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		path     string
+		line     int
+		wantPath string
+		wantLine int
+	}{
+		{"before insertion", insertionDiff, "f.txt", 2, "f.txt", 2},
+		{"after insertion", insertionDiff, "f.txt", 3, "f.txt", 5},
+		{"before deletion", deletionDiff, "f.txt", 2, "f.txt", 2},
+		{"deleted line", deletionDiff, "f.txt", 4, "f.txt", 0},
+		{"between hunks", deletionDiff, "f.txt", 5, "f.txt", 3},
+		{"changed line", deletionDiff, "f.txt", 8, "f.txt", 0},
+		{"after both hunks", deletionDiff, "f.txt", 9, "f.txt", 9},
+		{"unchanged file", deletionDiff, "other.txt", 4, "other.txt", 4},
+		{"pure rename", renameDiff, "old.txt", 7, "new.txt", 7},
+		{"renamed and changed line", renameDiff, "a.go", 1, "b.go", 0},
+		{"renamed and moved line", renameDiff, "a.go", 2, "b.go", 3},
+		{"deleted file", deletedFileDiff, "gone.txt", 1, "gone.txt", 0},
+		{"removed -- line", headerLikeDiff, "sig.txt", 2, "sig.txt", 0},
+		{"after removed -- line", headerLikeDiff, "sig.txt", 3, "sig.txt", 2},
+		{"before added ++ line", headerLikeDiff, "sig.txt", 5, "sig.txt", 4},
+		{"after added ++ line", headerLikeDiff, "sig.txt", 6, "sig.txt", 6},
+		{"path with a space", headerLikeDiff, "with space.txt", 2, "with space.txt", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseLineMapper(tt.diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path, line := m.MapLine(tt.path, tt.line)
+			if path != tt.wantPath || line != tt.wantLine {
+				t.Errorf("MapLine(%q, %d) = %q, %d, want %q, %d", tt.path, tt.line, path, line, tt.wantPath, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestParseLineMapperRejectsBadHunkHeader(t *testing.T) {
+	diff := "diff --git a/f.txt b/f.txt\n--- a/f.txt\n+++ b/f.txt\n@@ -x +1 @@\n"
+	if _, err := parseLineMapper(diff); err == nil {
+		t.Error("parsed an invalid hunk header")
+	}
+}
+
+func TestNewLineMapperIgnoresDiffConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=zip", "GIT_AUTHOR_EMAIL=zip@example.com",
+			"GIT_COMMITTER_NAME=zip", "GIT_COMMITTER_EMAIL=zip@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	write("ünï cödé.txt", "one\ntwo\nthree\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	from := run("rev-parse", "HEAD")
+	write("ünï cödé.txt", "zero\none\ntwo\nthree\n")
+	run("commit", "-q", "-am", "second")
+	for _, setting := range []string{"diff.noprefix=true", "diff.mnemonicPrefix=true", "core.quotePath=true"} {
+		key, value, _ := strings.Cut(setting, "=")
+		run("config", key, value)
+	}
+
+	repo, err := OpenRepo(dir, filepath.Join(dir, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := repo.NewLineMapper(from, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, line := m.MapLine("ünï cödé.txt", 2); path != "ünï cödé.txt" || line != 3 {
+		t.Errorf("MapLine = %q, %d, want line 3 of the same file", path, line)
+	}
+}
@@ -20,18 +20,19 @@ type CommitInfo struct {
 }
 
 type LogOptions struct {
-	RevisionRange    []string
+	RevisionRange []string
+	// SpecificToBranch follows only the branch's own commits: merges are
+	// skipped and only first parents are walked. RevisionRange still applies.
 	SpecificToBranch bool
 }
 
 func (r *Repo) FetchGitLog(opts LogOptions) ([]*CommitInfo, error) {
-	args := append([]string{"log", "--format=%H%x00%h%x00%s%x00%b%x00%ct%x00"})
+	args := []string{"log", "--format=%H%x00%h%x00%s%x00%b%x00%ct%x00"}
 
 	if opts.SpecificToBranch {
 		args = append(args, "--no-merges", "--first-parent")
-	} else {
-		args = append(args, opts.RevisionRange...)
 	}
+	args = append(args, opts.RevisionRange...)
 
 	args = append(args, "--")
 
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"emperror.dev/errors"
+	"slices"
+	"strings"
+)
+
+type Remote struct {
+	Name     string
+	FetchURL string
+	PushURL  string
+}
+
+type remoteHead struct {
+	branch string
+	err    error
+}
+
+// Remotes lists the repository's remotes, sorted by name. The list is read
+// once per Repo.
+func (r *Repo) Remotes() ([]Remote, error) {
+	if r.remotes != nil {
+		return r.remotes, nil
+	}
+	out, err := r.Git("remote", "-v")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list remotes")
+	}
+
+	byName := make(map[string]*Remote)
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		// <name>\t<url> (fetch|push)
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		remote, ok := byName[fields[0]]
+		if !ok {
+			remote = &Remote{Name: fields[0]}
+			byName[fields[0]] = remote
+			names = append(names, fields[0])
+		}
+		switch fields[2] {
+		case "(fetch)":
+			remote.FetchURL = fields[1]
+		case "(push)":
+			remote.PushURL = fields[1]
+		}
+	}
+
+	slices.Sort(names)
+	remotes := make([]Remote, 0, len(names))
+	for _, name := range names {
+		remotes = append(remotes, *byName[name])
+	}
+	r.remotes = remotes
+	return remotes, nil
+}
+
+// HasRemote reports whether a remote with the given name exists.
+func (r *Repo) HasRemote(name string) (bool, error) {
+	remotes, err := r.Remotes()
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(remotes, func(remote Remote) bool {
+		return remote.Name == name
+	}), nil
+}
+
+// PushRemote returns the remote branches are pushed to: zip.pushRemote if
+// set, otherwise origin, otherwise the only remote. It returns
+// ErrRemoteNotFound if there is no suitable remote.
+func (r *Repo) PushRemote() (string, error) {
+	return r.resolveRemote("pushremote", "")
+}
+
+// BaseRemote returns the remote pull requests target: zip.baseRemote if set,
+// otherwise the push remote.
+func (r *Repo) BaseRemote() (string, error) {
+	push, err := r.PushRemote()
+	if err != nil {
+		return "", err
+	}
+	return r.resolveRemote("baseremote", push)
+}
+
+func (r *Repo) resolveRemote(configKey, fallback string) (string, error) {
+	remotes, err := r.Remotes()
+	if err != nil {
+		return "", err
+	}
+	if len(remotes) == 0 {
+		return "", ErrRemoteNotFound
+	}
+	exists := func(name string) bool {
+		return slices.ContainsFunc(remotes, func(remote Remote) bool {
+			return remote.Name == name
+		})
+	}
+
+	if name, ok := r.cfg.Get(configKey); ok && name != "" {
+		if !exists(name) {
+			return "", errors.WrapIff(ErrRemoteNotFound, "zip.%s is set to %q", configKey, name)
+		}
+		return name, nil
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	if exists(DEFAULT_REMOTE_NAME) {
+		return DEFAULT_REMOTE_NAME, nil
+	}
+	if len(remotes) == 1 {
+		return remotes[0].Name, nil
+	}
+	return "", errors.WrapIff(ErrRemoteNotFound, "found %d remotes, set zip.%s to pick one", len(remotes), configKey)
+}
+
+// RemoteHead returns the default branch of a remote. It uses the locally
+// known refs/remotes/<remote>/HEAD and falls back to asking the remote. The
+// result, including a failure, is remembered for the lifetime of the Repo.
+func (r *Repo) RemoteHead(remote string) (string, error) {
+	if head, ok := r.remoteHeads[remote]; ok {
+		return head.branch, head.err
+	}
+	branch, err := r.resolveRemoteHead(remote)
+	r.remoteHeads[remote] = remoteHead{branch, err}
+	return branch, err
+}
+
+func (r *Repo) resolveRemoteHead(remote string) (string, error) {
+	prefix := "refs/remotes/" + remote + "/"
+	if ref, err := r.Git("symbolic-ref", prefix+"HEAD"); err == nil {
+		return strings.TrimPrefix(ref, prefix), nil
+	}
+
+	out, err := r.Git("ls-remote", "--symref", remote, "HEAD")
+	if err != nil {
+		return "", errors.WrapIff(err, "failed to determine HEAD of remote %s", remote)
+	}
+	// ref: refs/heads/main	HEAD
+	for _, line := range strings.Split(out, "\n") {
+		if target, ok := strings.CutPrefix(line, "ref: refs/heads/"); ok {
+			branch, _, _ := strings.Cut(target, "\t")
+			return branch, nil
+		}
+	}
+	return "", errors.Errorf("remote %s has no HEAD", remote)
+}
+
+// remoteForBranch returns where a branch lives: the trunk comes from the
+// base remote, everything else from the push remote.
+func (r *Repo) remoteForBranch(branchName string) (string, error) {
+	base, err := r.BaseRemote()
+	if err != nil {
+		return "", err
+	}
+	if trunk, err := r.RemoteHead(base); err == nil && trunk == branchName {
+		return base, nil
+	}
+	return r.PushRemote()
+}
@@ -18,19 +18,43 @@ type Origin struct {
 	RepoSlug string
 }
 
+// Host returns the origin's host name without any port, e.g. "github.com".
+func (o *Origin) Host() string {
+	return o.URL.Hostname()
+}
+
+// GetOrigin returns the repository pull requests are opened against.
 func (r *Repo) GetOrigin() (*Origin, error) {
-	result, err := r.Git("remote", "get-url", "origin")
+	name, err := r.BaseRemote()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetRemote(name)
+}
+
+// GetPushOrigin returns the repository branches are pushed to.
+func (r *Repo) GetPushOrigin() (*Origin, error) {
+	name, err := r.PushRemote()
+	if err != nil {
+		return nil, err
+	}
+	return r.GetRemote(name)
+}
+
+// GetRemote parses the URL of the named remote.
+func (r *Repo) GetRemote(name string) (*Origin, error) {
+	result, err := r.Git("remote", "get-url", name)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get origin URL")
+		return nil, errors.WrapIff(err, "failed to get %s URL", name)
 	}
 	origin := strings.TrimSpace(string(result))
 	if origin == "" {
-		return nil, errors.New("origin URL is empty")
+		return nil, errors.Errorf("%s URL is empty", name)
 	}
 
 	u, err := giturls.Parse(origin)
 	if err != nil {
-		return nil, errors.WrapIff(err, "failed to parse origin url %q", origin)
+		return nil, errors.WrapIff(err, "failed to parse %s url %q", name, origin)
 	}
 
 	repoSlug := strings.TrimSuffix(u.Path, ".git")
@@ -46,13 +70,28 @@ func (r *Repo) RemoteOwnerAndName() (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
+	return details.OwnerAndName()
+}
+
+// PushOwnerAndName returns the owner and name of the repository branches are
+// pushed to, which is the fork in a fork workflow.
+func (r *Repo) PushOwnerAndName() (string, string, error) {
+	details, err := r.GetPushOrigin()
+	if err != nil {
+		return "", "", err
+	}
+	return details.OwnerAndName()
+}
 
-	parts := strings.Split(details.RepoSlug, "/")
-	if len(parts) != 2 {
-		return "", "", errors.New("unexpected format")
+// OwnerAndName splits the repo slug into its owner and name. The owner may
+// contain slashes for nested groups, e.g. "group/subgroup" on GitLab.
+func (o *Origin) OwnerAndName() (string, string, error) {
+	i := strings.LastIndex(o.RepoSlug, "/")
+	if i <= 0 || i == len(o.RepoSlug)-1 {
+		return "", "", errors.Errorf("unexpected format for repository %q, expected owner/name", o.RepoSlug)
 	}
 
-	return parts[0], parts[1], nil
+	return o.RepoSlug[:i], o.RepoSlug[i+1:], nil
 }
 
 func (r *Repo) Fetch() error {
@@ -0,0 +1,143 @@
+package git
+
+import (
+	"emperror.dev/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrPRTemplateNotFound = errors.Sentinel("this repository doesn't have a pull request template")
+
+// DefaultPRTemplateName is the name given to a single pull_request_template.md.
+const DefaultPRTemplateName = "default"
+
+// prTemplateDirs are the directories GitHub searches for templates.
+var prTemplateDirs = []string{".github", "", "docs"}
+
+type PRTemplate struct {
+	// Name is the file name without extension, or DefaultPRTemplateName for a
+	// single pull_request_template.md.
+	Name    string
+	Path    string
+	Content string
+}
+
+// PRTemplates returns every pull request template in the repo. It looks for
+// pull_request_template.md and PULL_REQUEST_TEMPLATE/*.md in .github/, the
+// root and docs/, matching names case-insensitively like GitHub does.
+func (r *Repo) PRTemplates() ([]PRTemplate, error) {
+	var templates []PRTemplate
+	seen := make(map[string]bool)
+
+	for _, dir := range prTemplateDirs {
+		entries, err := os.ReadDir(filepath.Join(r.repoDir, dir))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WrapIff(err, "failed to read %s", dir)
+		}
+
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			path := filepath.Join(dir, entry.Name())
+
+			switch {
+			case !entry.IsDir() && isTemplateFile(name) && strings.TrimSuffix(name, filepath.Ext(name)) == "pull_request_template":
+				if seen[DefaultPRTemplateName] {
+					continue
+				}
+				tmpl, err := r.readPRTemplate(DefaultPRTemplateName, path)
+				if err != nil {
+					return nil, err
+				}
+				seen[DefaultPRTemplateName] = true
+				templates = append(templates, *tmpl)
+			case entry.IsDir() && name == "pull_request_template":
+				named, err := r.readPRTemplateDir(path)
+				if err != nil {
+					return nil, err
+				}
+				for _, tmpl := range named {
+					if seen[tmpl.Name] {
+						continue
+					}
+					seen[tmpl.Name] = true
+					templates = append(templates, tmpl)
+				}
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// GetPRTemplate returns the contents of the default PR template, or of the
+// first named template if the repo only has a template directory.
+func (r *Repo) GetPRTemplate() (string, error) {
+	templates, err := r.PRTemplates()
+	if err != nil {
+		return "", err
+	}
+	if len(templates) == 0 {
+		return "", ErrPRTemplateNotFound
+	}
+	return templates[0].Content, nil
+}
+
+// GetPRTemplateByName returns the contents of the template with the given name.
+func (r *Repo) GetPRTemplateByName(name string) (string, error) {
+	templates, err := r.PRTemplates()
+	if err != nil {
+		return "", err
+	}
+	for _, tmpl := range templates {
+		if strings.EqualFold(tmpl.Name, name) {
+			return tmpl.Content, nil
+		}
+	}
+	return "", errors.WrapIff(ErrPRTemplateNotFound, "template %q", name)
+}
+
+func (r *Repo) readPRTemplateDir(dir string) ([]PRTemplate, error) {
+	entries, err := os.ReadDir(filepath.Join(r.repoDir, dir))
+	if err != nil {
+		return nil, errors.WrapIff(err, "failed to read %s", dir)
+	}
+
+	var templates []PRTemplate
+	for _, entry := range entries {
+		if entry.IsDir() || !isTemplateFile(strings.ToLower(entry.Name())) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		tmpl, err := r.readPRTemplate(name, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+func (r *Repo) readPRTemplate(name, path string) (*PRTemplate, error) {
+	contents, err := os.ReadFile(filepath.Join(r.repoDir, path))
+	if err != nil {
+		return nil, errors.WrapIff(err, "failed to read PR template %s", path)
+	}
+	return &PRTemplate{
+		Name:    name,
+		Path:    path,
+		Content: string(contents),
+	}, nil
+}
+
+func isTemplateFile(name string) bool {
+	return strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".txt")
+}
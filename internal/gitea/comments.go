@@ -0,0 +1,75 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"zip/internal/gh"
+)
+
+type comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// FindStackComment searches for the stack comment in a pull request
+func (c *Client) FindStackComment(ctx context.Context, number int) (*gh.Comment, error) {
+	query := url.Values{"limit": {strconv.Itoa(pageSize)}}
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var comments []*comment
+		if err := c.do(ctx, http.MethodGet, c.repoPath("/issues/%d/comments", number), query, nil, &comments); err != nil {
+			return nil, fmt.Errorf("failed to list comments: %w", err)
+		}
+
+		for _, cm := range comments {
+			if converted := convertToComment(cm); converted.IsStackComment() {
+				return converted, nil
+			}
+		}
+
+		if len(comments) < pageSize {
+			break
+		}
+	}
+
+	return nil, nil // Comment not found
+}
+
+// AddComment adds a new comment to a pull request
+func (c *Client) AddComment(ctx context.Context, number int, body string) (*gh.Comment, error) {
+	var cm comment
+	if err := c.do(ctx, http.MethodPost, c.repoPath("/issues/%d/comments", number), nil, map[string]any{"body": body}, &cm); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return convertToComment(&cm), nil
+}
+
+// UpdateComment replaces the body of an existing comment. Comment IDs are
+// unique per repository, so number is unused.
+func (c *Client) UpdateComment(ctx context.Context, number int, commentID int64, body string) (*gh.Comment, error) {
+	var cm comment
+	if err := c.do(ctx, http.MethodPatch, c.repoPath("/issues/comments/%d", commentID), nil, map[string]any{"body": body}, &cm); err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	return convertToComment(&cm), nil
+}
+
+// RemoveComment removes a specific comment from a pull request
+func (c *Client) RemoveComment(ctx context.Context, number int, commentID int64) error {
+	if err := c.do(ctx, http.MethodDelete, c.repoPath("/issues/comments/%d", commentID), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+func convertToComment(cm *comment) *gh.Comment {
+	return &gh.Comment{
+		ID:   cm.ID,
+		Body: cm.Body,
+		URL:  cm.HTMLURL,
+	}
+}
@@ -0,0 +1,252 @@
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"zip/internal/config"
+	"zip/internal/gh"
+)
+
+// pageSize is the number of items requested per page. Gitea caps it at
+// [api].MAX_RESPONSE_ITEMS, which defaults to 50.
+const pageSize = 50
+
+// Client talks to the Gitea REST API (v1) for a single repository. Forgejo
+// serves the same API, so it is covered too. It implements gh.Forge.
+type Client struct {
+	http    *http.Client
+	baseURL *url.URL
+	token   string
+	host    string
+	owner   string
+	repo    string
+}
+
+var _ gh.Forge = (*Client)(nil)
+
+// Options configures a Gitea client.
+type Options struct {
+	Owner      string
+	Repo       string
+	Host       string
+	Tokens     gh.TokenProvider
+	HTTPClient *http.Client
+	Config     *config.Config
+	// Dir is the repository directory, used to load git config and run
+	// git credential helpers.
+	Dir string
+}
+
+// New creates a Gitea client.
+func New(opts Options) (*Client, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, fmt.Errorf("failed to initialize Gitea client: owner and repo are required")
+	}
+	if opts.Host == "" {
+		return nil, fmt.Errorf("failed to initialize Gitea client: host is required")
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load(opts.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Gitea client: %w", err)
+		}
+	}
+
+	tokens := opts.Tokens
+	if tokens == nil {
+		tokens = DefaultTokenChain(cfg, opts.Host, opts.Dir)
+	}
+	token, err := tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Gitea client: %w", err)
+	}
+
+	apiURL := "https://" + opts.Host + "/api/v1/"
+	if override, ok := cfg.HostValue(opts.Host, "apiurl"); ok && override != "" {
+		apiURL = override
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	baseURL, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Gitea API URL for %s: %w", opts.Host, err)
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	return &Client{
+		http:    httpClient,
+		baseURL: baseURL,
+		token:   token,
+		host:    opts.Host,
+		owner:   opts.Owner,
+		repo:    opts.Repo,
+	}, nil
+}
+
+// GetOwner returns the repository owner
+func (c *Client) GetOwner() string {
+	return c.owner
+}
+
+// GetRepo returns the repository name
+func (c *Client) GetRepo() string {
+	return c.repo
+}
+
+// GetHost returns the Gitea host
+func (c *Client) GetHost() string {
+	return c.host
+}
+
+// EnvTokenProvider reads GITEA_TOKEN, then FORGEJO_TOKEN.
+type EnvTokenProvider struct{}
+
+func (EnvTokenProvider) Name() string { return "GITEA_TOKEN/FORGEJO_TOKEN environment variables" }
+
+func (EnvTokenProvider) Token() (string, error) {
+	for _, name := range []string{"GITEA_TOKEN", "FORGEJO_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// ConfigTokenProvider reads zip.<host>.token from git config. Unlike
+// gh.ConfigTokenProvider it never falls back to zip.token, which holds a
+// GitHub token.
+type ConfigTokenProvider struct {
+	Config *config.Config
+	Host   string
+}
+
+func (p ConfigTokenProvider) Name() string {
+	return fmt.Sprintf("zip.%s.token git config", p.Host)
+}
+
+func (p ConfigTokenProvider) Token() (string, error) {
+	if token, ok := p.Config.Get(p.Host + ".token"); ok && strings.TrimSpace(token) != "" {
+		return strings.TrimSpace(token), nil
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// DefaultTokenChain returns the lookup order for Gitea tokens: environment,
+// zip config and git credential helpers.
+func DefaultTokenChain(cfg *config.Config, host, dir string) gh.TokenChain {
+	return gh.TokenChain{Forge: "Gitea", Providers: []gh.TokenProvider{
+		EnvTokenProvider{},
+		ConfigTokenProvider{Config: cfg, Host: host},
+		gh.GitCredentialTokenProvider{Host: host, Dir: dir},
+	}}
+}
+
+// Error is a non-2xx response from the Gitea API.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the status code, see gh.StatusCode.
+func (e *Error) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// isNotFound reports whether err is a 404 from the Gitea API.
+func isNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// repoPath returns the API path of a resource in the repository.
+func (c *Client) repoPath(format string, args ...any) string {
+	return "repos/" + url.PathEscape(c.owner) + "/" + url.PathEscape(c.repo) + fmt.Sprintf(format, args...)
+}
+
+// do sends a request and decodes the JSON response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	u := c.baseURL.ResolveReference(ref)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &Error{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(resp.Body),
+		}
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// errorMessage extracts Gitea's "message" field from an error response,
+// falling back to the raw body.
+func errorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 4096))
+	var parsed struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &parsed) == nil && parsed.Message != "" {
+		return parsed.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
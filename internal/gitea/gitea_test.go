@@ -0,0 +1,285 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"zip/internal/config"
+	"zip/internal/gh"
+	"zip/internal/gitea/giteatest"
+)
+
+// countingTransport records the paths of the requests sent through it.
+type countingTransport struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.paths = append(t.paths, req.Method+" "+req.URL.Path)
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (t *countingTransport) reset() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	paths := t.paths
+	t.paths = nil
+	return paths
+}
+
+func newTestClient(t *testing.T) (*Client, *giteatest.Server, *countingTransport) {
+	t.Helper()
+	srv := giteatest.NewServer("owner", "repo")
+	t.Cleanup(srv.Close)
+
+	transport := &countingTransport{}
+	client, err := New(Options{
+		Owner:      "owner",
+		Repo:       "repo",
+		Host:       "gitea.test",
+		Tokens:     gh.StaticTokenProvider(giteatest.Token),
+		HTTPClient: &http.Client{Transport: transport},
+		Config:     config.Parse([]byte("zip.gitea.test.apiurl\n" + srv.APIURL() + "\x00")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, srv, transport
+}
+
+func TestPullRequestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, srv, _ := newTestClient(t)
+
+	pr, err := client.CreatePullRequest(ctx, "Add feature", "body", "feature", "main", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Number != 1 || pr.State != "open" || !pr.IsDraft || pr.BaseRefName != "main" || pr.HeadRefName != "feature" {
+		t.Fatalf("unexpected created pull request: %+v", pr)
+	}
+	if got := srv.PullRequests()[0].Title; got != "WIP: Add feature" {
+		t.Errorf("draft title = %q, want it prefixed with WIP", got)
+	}
+
+	title := "Add the feature"
+	if pr, err = client.UpdatePullRequest(ctx, pr.Number, &title, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if pr.Title != title || pr.Body != "body" {
+		t.Errorf("updated pull request = %q/%q, want %q/%q", pr.Title, pr.Body, title, "body")
+	}
+
+	if pr, err = client.RetargetPullRequest(ctx, pr.Number, "develop"); err != nil {
+		t.Fatal(err)
+	}
+	if pr.BaseRefName != "develop" {
+		t.Errorf("base after retarget = %q, want develop", pr.BaseRefName)
+	}
+
+	got, err := client.GetPullRequest(ctx, pr.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != title || got.BaseRefName != "develop" {
+		t.Errorf("GetPullRequest = %+v", got)
+	}
+	if got.MergeableState != "clean" {
+		t.Errorf("mergeable state = %q, want clean", got.MergeableState)
+	}
+
+	// Gitea reports false while it checks the new base for conflicts.
+	if err := srv.SetMergeable(pr.Number, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = client.GetPullRequest(ctx, pr.Number); err != nil {
+		t.Fatal(err)
+	}
+	if got.IsMergeableStateKnown() {
+		t.Errorf("mergeable state = %q while checking, want it unknown", got.MergeableState)
+	}
+
+	if _, err := client.GetPullRequest(ctx, 42); err == nil {
+		t.Error("GetPullRequest of a missing pull request succeeded")
+	}
+}
+
+func TestGetPullRequests(t *testing.T) {
+	ctx := context.Background()
+	client, srv, transport := newTestClient(t)
+
+	for _, branch := range []string{"a", "b", "c"} {
+		if _, err := client.CreatePullRequest(ctx, branch, "", branch, "main", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.CreatePullRequest(ctx, "d", "", "d", "a", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Merge(2, "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input gh.GetPullRequestsInput
+		want  []int
+	}{
+		{gh.GetPullRequestsInput{}, []int{4, 3, 1}},
+		{gh.GetPullRequestsInput{State: "all", Sort: "created", Dir: "asc"}, []int{1, 2, 3, 4}},
+		{gh.GetPullRequestsInput{Base: "main"}, []int{3, 1}},
+		{gh.GetPullRequestsInput{Head: "owner:d"}, []int{4}},
+		{gh.GetPullRequestsInput{Head: "d", Base: "a"}, []int{4}},
+		{gh.GetPullRequestsInput{Head: "b", Base: "main"}, nil},
+		{gh.GetPullRequestsInput{Head: "b", Base: "main", State: "closed"}, []int{2}},
+		{gh.GetPullRequestsInput{Head: "missing", Base: "main", State: "all"}, nil},
+	}
+	for _, tt := range tests {
+		prs, err := client.GetPullRequests(ctx, tt.input)
+		if err != nil {
+			t.Fatalf("GetPullRequests(%+v): %v", tt.input, err)
+		}
+		var got []int
+		for _, pr := range prs {
+			got = append(got, pr.Number)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GetPullRequests(%+v) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	merged, err := client.GetPullRequest(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.State != "merged" || merged.MergeCommit != "abc" {
+		t.Errorf("merged pull request has state %q and merge commit %q", merged.State, merged.MergeCommit)
+	}
+
+	transport.reset()
+	if _, err := client.GetPullRequests(ctx, gh.GetPullRequestsInput{Head: "d", Base: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if paths := transport.reset(); len(paths) != 1 || !strings.HasSuffix(paths[0], "/pulls/a/d") {
+		t.Errorf("lookup by head and base sent %v, want a single /pulls/a/d request", paths)
+	}
+}
+
+func TestStackComment(t *testing.T) {
+	ctx := context.Background()
+	client, srv, _ := newTestClient(t)
+
+	pr, err := client.CreatePullRequest(ctx, "feature", "", "feature", "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.AddComment(ctx, pr.Number, "LGTM"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := client.FindStackComment(ctx, pr.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != nil {
+		t.Fatalf("found stack comment %+v before one was added", found)
+	}
+
+	body := gh.StackCommentIdentifier + "\n- #1"
+	created, err := gh.UpsertStackComment(ctx, client, pr.Number, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := gh.UpsertStackComment(ctx, client, pr.Number, body+"\n- #2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != created.ID {
+		t.Errorf("upsert created comment %d instead of updating %d", updated.ID, created.ID)
+	}
+
+	comments := srv.Comments(pr.Number)
+	if len(comments) != 2 || comments[1].Body != body+"\n- #2" {
+		t.Fatalf("comments = %+v, want LGTM and the updated stack comment", comments)
+	}
+
+	if err := client.RemoveComment(ctx, pr.Number, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if found, err = client.FindStackComment(ctx, pr.Number); err != nil || found != nil {
+		t.Errorf("FindStackComment after removal = %+v, %v", found, err)
+	}
+}
+
+func TestIsBranchMerged(t *testing.T) {
+	ctx := context.Background()
+	client, srv, transport := newTestClient(t)
+
+	// More than a page of merged pull requests, the newest from "last".
+	branches := make([]string, pageSize+10)
+	for i := range branches {
+		branches[i] = fmt.Sprintf("branch-%d", i)
+	}
+	branches[len(branches)-1] = "last"
+	for i, branch := range branches {
+		if _, err := client.CreatePullRequest(ctx, branch, "", branch, "main", false); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.Merge(i+1, fmt.Sprintf("%040x", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.CreatePullRequest(ctx, "open", "", "open", "main", false); err != nil {
+		t.Fatal(err)
+	}
+
+	transport.reset()
+	merged, err := client.IsBranchMerged(ctx, "last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !merged {
+		t.Error("IsBranchMerged(last) = false, want true")
+	}
+	if pages := countLists(transport.reset()); pages != 1 {
+		t.Errorf("IsBranchMerged(last) listed %d pages, want to stop at the first match", pages)
+	}
+
+	if merged, err = client.IsBranchMerged(ctx, "branch-0"); err != nil || !merged {
+		t.Errorf("IsBranchMerged(branch-0) = %v, %v, want true", merged, err)
+	}
+	if pages := countLists(transport.reset()); pages != 2 {
+		t.Errorf("IsBranchMerged(branch-0) listed %d pages, want 2", pages)
+	}
+
+	if merged, err = client.IsBranchMerged(ctx, "open"); err != nil || merged {
+		t.Errorf("IsBranchMerged(open) = %v, %v, want false", merged, err)
+	}
+
+	// A branch pushed after every merge can't have been merged by any of
+	// them, so nothing beyond the first page is looked at.
+	srv.SetBranch("fresh", time.Now().Add(time.Minute))
+	transport.reset()
+	if merged, err = client.IsBranchMerged(ctx, "fresh"); err != nil || merged {
+		t.Errorf("IsBranchMerged(fresh) = %v, %v, want false", merged, err)
+	}
+	if pages := countLists(transport.reset()); pages != 1 {
+		t.Errorf("IsBranchMerged(fresh) listed %d pages, want 1", pages)
+	}
+}
+
+func countLists(paths []string) int {
+	n := 0
+	for _, path := range paths {
+		if strings.HasSuffix(path, "/pulls") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,488 @@
+// Package giteatest provides an in-memory stand-in for the parts of the Gitea
+// API zip uses, so the Gitea backend can be exercised without a real forge.
+package giteatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is the only token the server accepts.
+const Token = "giteatest-token"
+
+type Branch struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+type PullRequest struct {
+	ID                 int64      `json:"id"`
+	Number             int        `json:"number"`
+	Title              string     `json:"title"`
+	Body               string     `json:"body"`
+	State              string     `json:"state"`
+	Merged             bool       `json:"merged"`
+	Mergeable          bool       `json:"mergeable"`
+	MergedAt           *time.Time `json:"merged_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	MergeCommitSHA     string     `json:"merge_commit_sha"`
+	HTMLURL            string     `json:"html_url"`
+	Head               Branch     `json:"head"`
+	Base               Branch     `json:"base"`
+	RequestedReviewers []string   `json:"-"`
+	RequestedTeams     []string   `json:"-"`
+}
+
+type Comment struct {
+	ID      int64  `json:"id"`
+	Issue   int    `json:"-"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Server serves a single repository, owner/repo, over httptest.
+type Server struct {
+	*httptest.Server
+	Owner string
+	Repo  string
+
+	mu       sync.Mutex
+	nextID   int64
+	pulls    []*PullRequest
+	comments []*Comment
+	branches map[string]time.Time
+}
+
+var routes = []struct {
+	method  string
+	pattern *regexp.Regexp
+	handle  func(s *Server, w http.ResponseWriter, r *http.Request, args []string)
+}{
+	{http.MethodGet, regexp.MustCompile(`^$`), (*Server).getRepo},
+	{http.MethodGet, regexp.MustCompile(`^/pulls$`), (*Server).listPulls},
+	{http.MethodPost, regexp.MustCompile(`^/pulls$`), (*Server).createPull},
+	{http.MethodGet, regexp.MustCompile(`^/pulls/(\d+)$`), (*Server).getPull},
+	{http.MethodPatch, regexp.MustCompile(`^/pulls/(\d+)$`), (*Server).editPull},
+	{http.MethodGet, regexp.MustCompile(`^/pulls/([^/]+)/(.+)$`), (*Server).getPullByBranches},
+	{http.MethodPost, regexp.MustCompile(`^/pulls/(\d+)/requested_reviewers$`), (*Server).requestReviewers},
+	{http.MethodPost, regexp.MustCompile(`^/pulls/(\d+)/merge$`), (*Server).mergePull},
+	{http.MethodGet, regexp.MustCompile(`^/branches/(.+)$`), (*Server).getBranch},
+	{http.MethodGet, regexp.MustCompile(`^/issues/(\d+)/comments$`), (*Server).listComments},
+	{http.MethodPost, regexp.MustCompile(`^/issues/(\d+)/comments$`), (*Server).createComment},
+	{http.MethodPatch, regexp.MustCompile(`^/issues/comments/(\d+)$`), (*Server).editComment},
+	{http.MethodDelete, regexp.MustCompile(`^/issues/comments/(\d+)$`), (*Server).deleteComment},
+}
+
+// NewServer starts a server for owner/repo. Point a client at APIURL and
+// authenticate with Token. Call Close when done.
+func NewServer(owner, repo string) *Server {
+	s := &Server{Owner: owner, Repo: repo, branches: make(map[string]time.Time)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// APIURL returns the base URL of the API, for the zip.<host>.apiURL setting.
+func (s *Server) APIURL() string {
+	return s.URL + "/api/v1/"
+}
+
+// PullRequests returns a copy of the pull requests, oldest first.
+func (s *Server) PullRequests() []PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]PullRequest, 0, len(s.pulls))
+	for _, pr := range s.pulls {
+		result = append(result, *pr)
+	}
+	return result
+}
+
+// Comments returns a copy of the comments on a pull request, oldest first.
+func (s *Server) Comments(number int) []Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Comment
+	for _, c := range s.comments {
+		if c.Issue == number {
+			result = append(result, *c)
+		}
+	}
+	return result
+}
+
+// SetBranch records that a branch exists on the server with its head commit
+// made at committed.
+func (s *Server) SetBranch(name string, committed time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.branches[name] = committed
+}
+
+// DeleteBranch removes a branch, as if it was deleted after merging.
+func (s *Server) DeleteBranch(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.branches, name)
+}
+
+// SetMergeable sets whether Gitea reports a pull request as mergeable, which
+// it doesn't while checking for conflicts or if there are any.
+func (s *Server) SetMergeable(number int, mergeable bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pr := s.pull(number)
+	if pr == nil {
+		return fmt.Errorf("pull request #%d does not exist", number)
+	}
+	pr.Mergeable = mergeable
+	return nil
+}
+
+// Merge marks a pull request as merged, as if it was merged in the web UI.
+func (s *Server) Merge(number int, mergeCommit string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pr := s.pull(number)
+	if pr == nil {
+		return fmt.Errorf("pull request #%d does not exist", number)
+	}
+	merge(pr, mergeCommit)
+	return nil
+}
+
+func merge(pr *PullRequest, mergeCommit string) {
+	now := time.Now()
+	pr.State = "closed"
+	pr.Merged = true
+	pr.MergedAt = &now
+	pr.UpdatedAt = now
+	pr.MergeCommitSHA = mergeCommit
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token "+Token {
+		writeError(w, http.StatusUnauthorized, "token is required")
+		return
+	}
+
+	prefix := fmt.Sprintf("/api/v1/repos/%s/%s", s.Owner, s.Repo)
+	path, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		writeError(w, http.StatusNotFound, "repository not found")
+		return
+	}
+
+	for _, route := range routes {
+		if route.method != r.Method {
+			continue
+		}
+		if args := route.pattern.FindStringSubmatch(path); args != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			route.handle(s, w, r, args[1:])
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "not found")
+}
+
+func (s *Server) getRepo(w http.ResponseWriter, _ *http.Request, _ []string) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":                s.Repo,
+		"full_name":           s.Owner + "/" + s.Repo,
+		"allow_merge_commits": true,
+		"allow_squash_merge":  true,
+		"allow_rebase":        true,
+	})
+}
+
+func (s *Server) listPulls(w http.ResponseWriter, r *http.Request, _ []string) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var matched []*PullRequest
+	for _, pr := range s.pulls {
+		if state == "all" || pr.State == state {
+			matched = append(matched, pr)
+		}
+	}
+	switch r.URL.Query().Get("sort") {
+	case "oldest":
+	case "recentupdate":
+		slices.SortStableFunc(matched, func(a, b *PullRequest) int {
+			return b.UpdatedAt.Compare(a.UpdatedAt)
+		})
+	default:
+		slices.Reverse(matched)
+	}
+	writeJSON(w, http.StatusOK, paginate(r, matched))
+}
+
+func (s *Server) createPull(w http.ResponseWriter, r *http.Request, _ []string) {
+	var input struct {
+		Title, Body, Head, Base string
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.Head == "" || input.Base == "" || input.Title == "" {
+		writeError(w, http.StatusUnprocessableEntity, "title, head and base are required")
+		return
+	}
+	for _, pr := range s.pulls {
+		if pr.State == "open" && pr.Head.Ref == input.Head && pr.Base.Ref == input.Base {
+			writeError(w, http.StatusConflict, "pull request already exists for these targets")
+			return
+		}
+	}
+
+	s.nextID++
+	pr := &PullRequest{
+		ID:        s.nextID,
+		Number:    s.nextNumber(),
+		Title:     input.Title,
+		Body:      input.Body,
+		State:     "open",
+		Mergeable: true,
+		UpdatedAt: time.Now(),
+		Head:      Branch{Ref: input.Head},
+		Base:      Branch{Ref: input.Base},
+	}
+	pr.HTMLURL = fmt.Sprintf("%s/%s/%s/pulls/%d", s.URL, s.Owner, s.Repo, pr.Number)
+	s.pulls = append(s.pulls, pr)
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+func (s *Server) getPull(w http.ResponseWriter, _ *http.Request, args []string) {
+	if pr := s.pullArg(w, args[0]); pr != nil {
+		writeJSON(w, http.StatusOK, pr)
+	}
+}
+
+func (s *Server) editPull(w http.ResponseWriter, r *http.Request, args []string) {
+	pr := s.pullArg(w, args[0])
+	if pr == nil {
+		return
+	}
+	var input struct {
+		Title, Body, State, Base *string
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.Title != nil {
+		pr.Title = *input.Title
+	}
+	if input.Body != nil {
+		pr.Body = *input.Body
+	}
+	if input.State != nil {
+		if pr.Merged {
+			writeError(w, http.StatusUnprocessableEntity, "pull request is already merged")
+			return
+		}
+		pr.State = *input.State
+	}
+	if input.Base != nil {
+		pr.Base.Ref = *input.Base
+	}
+	pr.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusCreated, pr)
+}
+
+// getPullByBranches returns the latest pull request from head into base.
+func (s *Server) getPullByBranches(w http.ResponseWriter, _ *http.Request, args []string) {
+	for _, pr := range slices.Backward(s.pulls) {
+		if pr.Base.Ref == args[0] && pr.Head.Ref == args[1] {
+			writeJSON(w, http.StatusOK, pr)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "pull request does not exist")
+}
+
+func (s *Server) getBranch(w http.ResponseWriter, _ *http.Request, args []string) {
+	committed, ok := s.branches[args[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "branch does not exist")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":   args[0],
+		"commit": map[string]any{"timestamp": committed},
+	})
+}
+
+func (s *Server) requestReviewers(w http.ResponseWriter, r *http.Request, args []string) {
+	pr := s.pullArg(w, args[0])
+	if pr == nil {
+		return
+	}
+	var input struct {
+		Reviewers     []string `json:"reviewers"`
+		TeamReviewers []string `json:"team_reviewers"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	pr.RequestedReviewers = append(pr.RequestedReviewers, input.Reviewers...)
+	pr.RequestedTeams = append(pr.RequestedTeams, input.TeamReviewers...)
+	writeJSON(w, http.StatusCreated, []any{})
+}
+
+func (s *Server) mergePull(w http.ResponseWriter, r *http.Request, args []string) {
+	pr := s.pullArg(w, args[0])
+	if pr == nil {
+		return
+	}
+	// The server has no checks, so scheduled merges happen right away.
+	var input struct {
+		Do                     string
+		MergeWhenChecksSucceed bool `json:"merge_when_checks_succeed"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if !slices.Contains([]string{"merge", "squash", "rebase", "rebase-merge"}, input.Do) {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("unknown merge style %q", input.Do))
+		return
+	}
+	if pr.State != "open" {
+		writeError(w, http.StatusMethodNotAllowed, "pull request is not open")
+		return
+	}
+	merge(pr, fmt.Sprintf("%040x", pr.ID))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request, args []string) {
+	pr := s.pullArg(w, args[0])
+	if pr == nil {
+		return
+	}
+	var matched []*Comment
+	for _, c := range s.comments {
+		if c.Issue == pr.Number {
+			matched = append(matched, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, paginate(r, matched))
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, args []string) {
+	pr := s.pullArg(w, args[0])
+	if pr == nil {
+		return
+	}
+	var input struct{ Body string }
+	if !readJSON(w, r, &input) {
+		return
+	}
+	s.nextID++
+	c := &Comment{
+		ID:    s.nextID,
+		Issue: pr.Number,
+		Body:  input.Body,
+	}
+	c.HTMLURL = fmt.Sprintf("%s#issuecomment-%d", pr.HTMLURL, c.ID)
+	s.comments = append(s.comments, c)
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *Server) editComment(w http.ResponseWriter, r *http.Request, args []string) {
+	i := s.commentIndex(w, args[0])
+	if i < 0 {
+		return
+	}
+	var input struct{ Body string }
+	if !readJSON(w, r, &input) {
+		return
+	}
+	s.comments[i].Body = input.Body
+	writeJSON(w, http.StatusOK, s.comments[i])
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, _ *http.Request, args []string) {
+	i := s.commentIndex(w, args[0])
+	if i < 0 {
+		return
+	}
+	s.comments = slices.Delete(s.comments, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// nextNumber returns the next issue number. Pull requests and issues share
+// numbers on Gitea; the stand-in only has pull requests.
+func (s *Server) nextNumber() int {
+	return len(s.pulls) + 1
+}
+
+func (s *Server) pull(number int) *PullRequest {
+	for _, pr := range s.pulls {
+		if pr.Number == number {
+			return pr
+		}
+	}
+	return nil
+}
+
+func (s *Server) pullArg(w http.ResponseWriter, arg string) *PullRequest {
+	number, _ := strconv.Atoi(arg)
+	pr := s.pull(number)
+	if pr == nil {
+		writeError(w, http.StatusNotFound, "pull request does not exist")
+	}
+	return pr
+}
+
+func (s *Server) commentIndex(w http.ResponseWriter, arg string) int {
+	id, _ := strconv.ParseInt(arg, 10, 64)
+	i := slices.IndexFunc(s.comments, func(c *Comment) bool { return c.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "comment does not exist")
+	}
+	return i
+}
+
+// paginate applies Gitea's page and limit parameters. The default limit
+// matches Gitea's default of 30.
+func paginate[T any](r *http.Request, items []T) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 30
+	}
+	start := min((page-1)*limit, len(items))
+	end := min(start+limit, len(items))
+	return append([]T{}, items[start:end]...)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, out any) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
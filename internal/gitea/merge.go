@@ -0,0 +1,93 @@
+package gitea
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"zip/internal/gh"
+)
+
+// MergePullRequest merges a pull request with the given method and returns
+// it in its merged state. Gitea refuses the merge if headSHA is set and the
+// head has moved.
+func (c *Client) MergePullRequest(ctx context.Context, number int, method gh.MergeMethod, headSHA string) (*gh.PullRequest, error) {
+	body := map[string]any{"Do": string(method)}
+	if headSHA != "" {
+		body["head_commit_id"] = headSHA
+	}
+	err := c.do(ctx, http.MethodPost, c.repoPath("/pulls/%d/merge", number), nil, body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s pull request #%d: %w", method, number, err)
+	}
+	return c.GetPullRequest(ctx, number)
+}
+
+// EnableAutoMerge schedules a pull request to be merged once its required
+// checks pass. Gitea merges it right away if they already have. Gitea doesn't
+// report the schedule on the pull request, so the returned pull request has
+// AutoMerge set here rather than by the server.
+func (c *Client) EnableAutoMerge(ctx context.Context, ref gh.PullRequestRef, method gh.MergeMethod) (*gh.PullRequest, error) {
+	err := c.do(ctx, http.MethodPost, c.repoPath("/pulls/%d/merge", ref.Number), nil, map[string]any{
+		"Do":                        string(method),
+		"merge_when_checks_succeed": true,
+	}, nil)
+	var apiErr *Error
+	// A conflict means the pull request is already scheduled.
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict) {
+		return nil, fmt.Errorf("failed to enable auto-merge for pull request #%d: %w", ref.Number, err)
+	}
+
+	pr, err := c.GetPullRequest(ctx, ref.Number)
+	if err != nil {
+		return nil, err
+	}
+	pr.AutoMerge = pr.State == "open"
+	return pr, nil
+}
+
+// AllowedMergeMethods returns the merge methods enabled in the repository's
+// settings, in the order zip prefers them.
+func (c *Client) AllowedMergeMethods(ctx context.Context) ([]gh.MergeMethod, error) {
+	var repo struct {
+		AllowMergeCommits bool `json:"allow_merge_commits"`
+		AllowSquashMerge  bool `json:"allow_squash_merge"`
+		AllowRebase       bool `json:"allow_rebase"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.repoPath(""), nil, nil, &repo); err != nil {
+		return nil, fmt.Errorf("failed to get repository settings: %w", err)
+	}
+
+	var methods []gh.MergeMethod
+	if repo.AllowMergeCommits {
+		methods = append(methods, gh.MergeMethodMerge)
+	}
+	if repo.AllowSquashMerge {
+		methods = append(methods, gh.MergeMethodSquash)
+	}
+	if repo.AllowRebase {
+		methods = append(methods, gh.MergeMethodRebase)
+	}
+	return methods, nil
+}
+
+// ConvertPullRequestToDraft marks a pull request as work in progress by
+// prefixing its title.
+func (c *Client) ConvertPullRequestToDraft(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return c.setDraftState(ctx, ref.Number, true)
+}
+
+// MarkPullRequestReadyForReview removes the work in progress prefix of a
+// pull request.
+func (c *Client) MarkPullRequestReadyForReview(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return c.setDraftState(ctx, ref.Number, false)
+}
+
+func (c *Client) setDraftState(ctx context.Context, number int, draft bool) (*gh.PullRequest, error) {
+	pr, err := c.GetPullRequest(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return c.editPullRequest(ctx, number, map[string]any{"title": setDraft(pr.Title, draft)},
+		"failed to change draft state of pull request")
+}
@@ -0,0 +1,302 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"zip/internal/gh"
+)
+
+// draftPrefix marks a pull request as work in progress, which is how Gitea
+// represents drafts by default.
+const draftPrefix = "WIP: "
+
+var draftRegex = regexp.MustCompile(`(?i)^\s*(wip:|\[wip\]|draft:|\[draft\])\s*`)
+
+type branchRef struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+}
+
+type pullRequest struct {
+	ID             int64      `json:"id"`
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	State          string     `json:"state"`
+	Draft          bool       `json:"draft"`
+	Merged         bool       `json:"merged"`
+	Mergeable      bool       `json:"mergeable"`
+	MergedAt       *time.Time `json:"merged_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+	HTMLURL        string     `json:"html_url"`
+	Head           branchRef  `json:"head"`
+	Base           branchRef  `json:"base"`
+}
+
+// GetPullRequest retrieves a specific pull request by its number.
+func (c *Client) GetPullRequest(ctx context.Context, number int) (*gh.PullRequest, error) {
+	var pr pullRequest
+	if err := c.do(ctx, http.MethodGet, c.repoPath("/pulls/%d", number), nil, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return convertToPullRequest(&pr), nil
+}
+
+// GetPullRequests retrieves a list of pull requests. Gitea can't filter the
+// list by head or base, so those are applied to the listed results, unless
+// both are given and the pull request can be looked up directly.
+func (c *Client) GetPullRequests(ctx context.Context, input gh.GetPullRequestsInput) ([]*gh.PullRequest, error) {
+	query := url.Values{}
+	switch input.State {
+	case "":
+		query.Set("state", "open")
+	case "open", "closed", "all":
+		query.Set("state", input.State)
+	default:
+		return nil, fmt.Errorf("unsupported pull request state %q", input.State)
+	}
+	switch input.Sort {
+	case "created":
+		if input.Dir == "asc" {
+			query.Set("sort", "oldest")
+		} else {
+			query.Set("sort", "newest")
+		}
+	case "updated":
+		query.Set("sort", "recentupdate")
+	}
+
+	head := input.Head
+	if _, branch, found := strings.Cut(head, ":"); found {
+		head = branch
+	}
+
+	if head != "" && input.Base != "" {
+		pr, err := c.pullRequestByBranches(ctx, input.Base, head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pull request: %w", err)
+		}
+		if pr == nil {
+			return nil, nil
+		}
+		// The lookup returns the latest pull request between the branches;
+		// only an older one can have the requested state otherwise.
+		if state := query.Get("state"); state == "all" || pr.State == state {
+			return []*gh.PullRequest{convertToPullRequest(pr)}, nil
+		}
+	}
+
+	var result []*gh.PullRequest
+	err := c.listPullRequests(ctx, query, func(pr *pullRequest) bool {
+		if (head == "" || pr.Head.Ref == head) && (input.Base == "" || pr.Base.Ref == input.Base) {
+			result = append(result, convertToPullRequest(pr))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	return result, nil
+}
+
+// pullRequestByBranches returns the latest pull request from head into base,
+// or nil if there is none.
+func (c *Client) pullRequestByBranches(ctx context.Context, base, head string) (*pullRequest, error) {
+	var pr pullRequest
+	err := c.do(ctx, http.MethodGet, c.repoPath("/pulls/%s/%s", url.PathEscape(base), url.PathEscape(head)), nil, nil, &pr)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// listPullRequests pages through pull requests, passing each to visit until
+// it returns false.
+func (c *Client) listPullRequests(ctx context.Context, query url.Values, visit func(*pullRequest) bool) error {
+	query.Set("limit", strconv.Itoa(pageSize))
+
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var prs []*pullRequest
+		if err := c.do(ctx, http.MethodGet, c.repoPath("/pulls"), query, nil, &prs); err != nil {
+			return err
+		}
+		for _, pr := range prs {
+			if !visit(pr) {
+				return nil
+			}
+		}
+
+		if len(prs) < pageSize {
+			return nil
+		}
+	}
+}
+
+// CreatePullRequest creates a new pull request.
+func (c *Client) CreatePullRequest(ctx context.Context, title, body, head, base string, draft bool) (*gh.PullRequest, error) {
+	if draft {
+		title = setDraft(title, true)
+	}
+
+	var pr pullRequest
+	err := c.do(ctx, http.MethodPost, c.repoPath("/pulls"), nil, map[string]any{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+	}, &pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return convertToPullRequest(&pr), nil
+}
+
+// UpdatePullRequest updates an existing pull request.
+func (c *Client) UpdatePullRequest(ctx context.Context, number int, title, body *string, state *string) (*gh.PullRequest, error) {
+	update := map[string]any{}
+	if title != nil {
+		update["title"] = *title
+	}
+	if body != nil {
+		update["body"] = *body
+	}
+	if state != nil {
+		update["state"] = *state
+	}
+	return c.editPullRequest(ctx, number, update, "failed to update pull request")
+}
+
+// RetargetPullRequest changes the base branch of a pull request.
+func (c *Client) RetargetPullRequest(ctx context.Context, number int, base string) (*gh.PullRequest, error) {
+	return c.editPullRequest(ctx, number, map[string]any{"base": base},
+		fmt.Sprintf("failed to change base of pull request to %s", base))
+}
+
+// RequestReviewers requests reviewers for a pull request. Entries in
+// "org/team" form are requested as team reviewers.
+func (c *Client) RequestReviewers(ctx context.Context, number int, reviewers []string) (*gh.PullRequest, error) {
+	var users, teams []string
+	for _, reviewer := range reviewers {
+		reviewer = strings.TrimPrefix(reviewer, "@")
+		if _, team, found := strings.Cut(reviewer, "/"); found {
+			teams = append(teams, team)
+		} else {
+			users = append(users, reviewer)
+		}
+	}
+
+	err := c.do(ctx, http.MethodPost, c.repoPath("/pulls/%d/requested_reviewers", number), nil, map[string]any{
+		"reviewers":      users,
+		"team_reviewers": teams,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request reviews: %w", err)
+	}
+	return c.GetPullRequest(ctx, number)
+}
+
+// IsBranchMerged checks if a branch is merged by looking for merged pull
+// requests. Closed pull requests are walked most recently updated first; a
+// pull request merged from the branch was updated after the branch's head
+// commit, so the walk stops once results are older than that.
+func (c *Client) IsBranchMerged(ctx context.Context, branchName string) (bool, error) {
+	since, err := c.branchCommitTime(ctx, branchName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get branch %s: %w", branchName, err)
+	}
+
+	merged := false
+	err = c.listPullRequests(ctx, url.Values{"state": {"closed"}, "sort": {"recentupdate"}}, func(pr *pullRequest) bool {
+		if pr.UpdatedAt.Before(since) {
+			return false
+		}
+		if pr.Head.Ref == branchName && (pr.Merged || pr.MergedAt != nil) {
+			merged = true
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	return merged, nil
+}
+
+// branchCommitTime returns when the head commit of a branch on the server
+// was made, or the zero time if the branch doesn't exist there (anymore).
+func (c *Client) branchCommitTime(ctx context.Context, branchName string) (time.Time, error) {
+	var branch struct {
+		Commit struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"commit"`
+	}
+	err := c.do(ctx, http.MethodGet, c.repoPath("/branches/%s", url.PathEscape(branchName)), nil, nil, &branch)
+	if isNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return branch.Commit.Timestamp, nil
+}
+
+func (c *Client) editPullRequest(ctx context.Context, number int, update map[string]any, failure string) (*gh.PullRequest, error) {
+	var pr pullRequest
+	if err := c.do(ctx, http.MethodPatch, c.repoPath("/pulls/%d", number), nil, update, &pr); err != nil {
+		return nil, fmt.Errorf("%s: %w", failure, err)
+	}
+	return convertToPullRequest(&pr), nil
+}
+
+// setDraft adds or removes the work in progress prefix of a title.
+func setDraft(title string, draft bool) string {
+	title = draftRegex.ReplaceAllString(title, "")
+	if draft {
+		return draftPrefix + title
+	}
+	return title
+}
+
+// convertToPullRequest converts a Gitea pull request to a Stacked pull
+// request. Like GitHub's REST API, Gitea reports merged pull requests as
+// closed, so the state is corrected to "merged".
+func convertToPullRequest(pr *pullRequest) *gh.PullRequest {
+	state := pr.State
+	if pr.Merged || pr.MergedAt != nil {
+		state = "merged"
+	}
+	// Gitea only reports whether a pull request can be merged right now,
+	// which is also false while it is still checking for conflicts, e.g.
+	// right after a retarget. The API doesn't tell that apart from an
+	// actual conflict, so both are unknown rather than dirty.
+	mergeableState := "unknown"
+	if pr.Mergeable {
+		mergeableState = "clean"
+	}
+
+	return &gh.PullRequest{
+		ID:             strconv.FormatInt(pr.ID, 10),
+		Number:         pr.Number,
+		HeadRefName:    pr.Head.Ref,
+		HeadSHA:        pr.Head.Sha,
+		BaseRefName:    pr.Base.Ref,
+		IsDraft:        pr.Draft || draftRegex.MatchString(pr.Title),
+		Permalink:      pr.HTMLURL,
+		State:          state,
+		Title:          pr.Title,
+		Body:           pr.Body,
+		MergeCommit:    pr.MergeCommitSHA,
+		MergeableState: mergeableState,
+	}
+}
@@ -0,0 +1,250 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"zip/internal/config"
+	"zip/internal/gh"
+)
+
+// DefaultHost is the host of gitlab.com projects.
+const DefaultHost = "gitlab.com"
+
+// Client talks to the GitLab REST API (v4) for a single project. It
+// implements gh.Forge, mapping merge requests onto gh.PullRequest.
+type Client struct {
+	http    *http.Client
+	baseURL *url.URL
+	token   string
+	host    string
+	project string
+}
+
+var _ gh.Forge = (*Client)(nil)
+
+// Options configures a GitLab client.
+type Options struct {
+	// Project is the full path of the project, including any nested groups,
+	// e.g. "group/subgroup/project".
+	Project    string
+	Host       string
+	Tokens     gh.TokenProvider
+	HTTPClient *http.Client
+	Config     *config.Config
+	// Dir is the repository directory, used to load git config and run
+	// git credential helpers.
+	Dir string
+}
+
+// New creates a GitLab client.
+func New(opts Options) (*Client, error) {
+	if opts.Project == "" {
+		return nil, fmt.Errorf("failed to initialize GitLab client: project is required")
+	}
+	if opts.Host == "" {
+		opts.Host = DefaultHost
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		var err error
+		cfg, err = config.Load(opts.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize GitLab client: %w", err)
+		}
+	}
+
+	tokens := opts.Tokens
+	if tokens == nil {
+		tokens = DefaultTokenChain(cfg, opts.Host, opts.Dir)
+	}
+	token, err := tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GitLab client: %w", err)
+	}
+
+	apiURL := "https://" + opts.Host + "/api/v4/"
+	if override, ok := cfg.HostValue(opts.Host, "apiurl"); ok && override != "" {
+		apiURL = override
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	baseURL, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitLab API URL for %s: %w", opts.Host, err)
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	return &Client{
+		http:    httpClient,
+		baseURL: baseURL,
+		token:   token,
+		host:    opts.Host,
+		project: opts.Project,
+	}, nil
+}
+
+// GetHost returns the GitLab host
+func (c *Client) GetHost() string {
+	return c.host
+}
+
+// GetProject returns the full path of the project
+func (c *Client) GetProject() string {
+	return c.project
+}
+
+// EnvTokenProvider reads GITLAB_TOKEN, then GL_TOKEN.
+type EnvTokenProvider struct{}
+
+func (EnvTokenProvider) Name() string { return "GITLAB_TOKEN/GL_TOKEN environment variables" }
+
+func (EnvTokenProvider) Token() (string, error) {
+	for _, name := range []string{"GITLAB_TOKEN", "GL_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// ConfigTokenProvider reads zip.<host>.token from git config. Unlike
+// gh.ConfigTokenProvider it never falls back to zip.token, which holds a
+// GitHub token.
+type ConfigTokenProvider struct {
+	Config *config.Config
+	Host   string
+}
+
+func (p ConfigTokenProvider) Name() string {
+	return fmt.Sprintf("zip.%s.token git config", p.Host)
+}
+
+func (p ConfigTokenProvider) Token() (string, error) {
+	if token, ok := p.Config.Get(p.Host + ".token"); ok && strings.TrimSpace(token) != "" {
+		return strings.TrimSpace(token), nil
+	}
+	return "", fmt.Errorf("not set")
+}
+
+// DefaultTokenChain returns the lookup order for GitLab tokens: environment,
+// zip config and git credential helpers.
+func DefaultTokenChain(cfg *config.Config, host, dir string) gh.TokenChain {
+	return gh.TokenChain{Forge: "GitLab", Providers: []gh.TokenProvider{
+		EnvTokenProvider{},
+		ConfigTokenProvider{Config: cfg, Host: host},
+		gh.GitCredentialTokenProvider{Host: host, Dir: dir},
+	}}
+}
+
+// Error is a non-2xx response from the GitLab API.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the status code, see gh.StatusCode.
+func (e *Error) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// projectPath returns the API path of the project, with the project's full
+// path escaped into a single segment as GitLab requires.
+func (c *Client) projectPath(format string, args ...any) string {
+	return "projects/" + url.PathEscape(c.project) + fmt.Sprintf(format, args...)
+}
+
+// do sends a request and decodes the JSON response into out, if not nil. It
+// returns the page number from X-Next-Page, or 0 on the last page.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (int, error) {
+	// Parse the path on its own so the escaped project path survives.
+	ref, err := url.Parse(path)
+	if err != nil {
+		return 0, err
+	}
+	u := c.baseURL.ResolveReference(ref)
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, &Error{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(resp.Body),
+		}
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+	}
+
+	var next int
+	fmt.Sscanf(resp.Header.Get("X-Next-Page"), "%d", &next)
+	return next, nil
+}
+
+// errorMessage extracts GitLab's "message" or "error" field from an error
+// response, falling back to the raw body.
+func errorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 4096))
+	var parsed struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(raw, &parsed) == nil {
+		if parsed.Message != nil {
+			return fmt.Sprint(parsed.Message)
+		}
+		if parsed.Error != "" {
+			return parsed.Error
+		}
+	}
+	return strings.TrimSpace(string(raw))
+}
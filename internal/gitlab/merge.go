@@ -0,0 +1,100 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"zip/internal/gh"
+)
+
+// MergePullRequest merges a merge request. GitLab rebases or not according
+// to the project's merge method, so gh.MergeMethodRebase is rejected rather
+// than silently creating a merge commit.
+func (c *Client) MergePullRequest(ctx context.Context, number int, method gh.MergeMethod, headSHA string) (*gh.PullRequest, error) {
+	var squash bool
+	switch method {
+	case gh.MergeMethodMerge:
+	case gh.MergeMethodSquash:
+		squash = true
+	default:
+		return nil, fmt.Errorf("merge method %q is not supported on GitLab; set the project's merge method instead", method)
+	}
+
+	body := map[string]any{"squash": squash}
+	if headSHA != "" {
+		// GitLab answers 409 if the source branch has moved on.
+		body["sha"] = headSHA
+	}
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodPut, c.projectPath("/merge_requests/%d/merge", number), nil, body, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s merge request !%d: %w", method, number, err)
+	}
+	return convertToPullRequest(&mr), nil
+}
+
+// EnableAutoMerge sets a merge request to merge when its pipeline succeeds.
+// GitLab merges it right away if there is no pipeline to wait for.
+func (c *Client) EnableAutoMerge(ctx context.Context, ref gh.PullRequestRef, method gh.MergeMethod) (*gh.PullRequest, error) {
+	var squash bool
+	switch method {
+	case gh.MergeMethodMerge:
+	case gh.MergeMethodSquash:
+		squash = true
+	default:
+		return nil, fmt.Errorf("merge method %q is not supported on GitLab; set the project's merge method instead", method)
+	}
+
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodPut, c.projectPath("/merge_requests/%d/merge", ref.Number), nil, map[string]any{
+		"squash": squash,
+		// merge_when_pipeline_succeeds was renamed to auto_merge in GitLab
+		// 17; older versions ignore the new name and newer ones the old.
+		"merge_when_pipeline_succeeds": true,
+		"auto_merge":                   true,
+	}, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable auto-merge for merge request !%d: %w", ref.Number, err)
+	}
+	return convertToPullRequest(&mr), nil
+}
+
+// AllowedMergeMethods returns the merge methods the project allows. GitLab
+// picks merge commits, fast-forwards or rebases from the project's settings,
+// so this only reflects whether squashing is allowed.
+func (c *Client) AllowedMergeMethods(ctx context.Context) ([]gh.MergeMethod, error) {
+	var project struct {
+		SquashOption string `json:"squash_option"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, c.projectPath(""), nil, nil, &project); err != nil {
+		return nil, fmt.Errorf("failed to get project settings: %w", err)
+	}
+
+	switch project.SquashOption {
+	case "always":
+		return []gh.MergeMethod{gh.MergeMethodSquash}, nil
+	case "never":
+		return []gh.MergeMethod{gh.MergeMethodMerge}, nil
+	}
+	return []gh.MergeMethod{gh.MergeMethodMerge, gh.MergeMethodSquash}, nil
+}
+
+// ConvertPullRequestToDraft marks a merge request as a draft by prefixing
+// its title.
+func (c *Client) ConvertPullRequestToDraft(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return c.setDraftState(ctx, ref.Number, true)
+}
+
+// MarkPullRequestReadyForReview removes the draft prefix of a merge request.
+func (c *Client) MarkPullRequestReadyForReview(ctx context.Context, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	return c.setDraftState(ctx, ref.Number, false)
+}
+
+func (c *Client) setDraftState(ctx context.Context, number int, draft bool) (*gh.PullRequest, error) {
+	mr, err := c.getMergeRequest(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	}
+	return c.updateMergeRequest(ctx, number, map[string]any{"title": setDraft(mr.Title, draft)},
+		"failed to change draft state of merge request")
+}
@@ -0,0 +1,291 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"zip/internal/gh"
+)
+
+// draftPrefix marks a merge request as a draft. GitLab derives the draft
+// flag from the title, so this is how drafts are created and cleared.
+const draftPrefix = "Draft: "
+
+var draftRegex = regexp.MustCompile(`(?i)^\s*(\[draft\]|\(draft\)|draft:|draft\s-\s|\[wip\]|wip:)\s*`)
+
+type user struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type mergeRequest struct {
+	ID              int    `json:"id"`
+	IID             int    `json:"iid"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	State           string `json:"state"`
+	Draft           bool   `json:"draft"`
+	WorkInProgress  bool   `json:"work_in_progress"`
+	SourceBranch    string `json:"source_branch"`
+	SHA             string `json:"sha"`
+	TargetBranch    string `json:"target_branch"`
+	WebURL          string `json:"web_url"`
+	MergeCommitSHA  string `json:"merge_commit_sha"`
+	SquashCommitSHA string `json:"squash_commit_sha"`
+	Reviewers       []user `json:"reviewers"`
+	// MergeWhenPipelineSucceeds is set while auto-merge is enabled.
+	MergeWhenPipelineSucceeds bool `json:"merge_when_pipeline_succeeds"`
+	// DetailedMergeStatus is e.g. "mergeable", "not_approved" or "conflict".
+	DetailedMergeStatus string `json:"detailed_merge_status"`
+}
+
+// GetPullRequest retrieves a merge request by its IID.
+func (c *Client) GetPullRequest(ctx context.Context, number int) (*gh.PullRequest, error) {
+	mr, err := c.getMergeRequest(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	}
+	return convertToPullRequest(mr), nil
+}
+
+func (c *Client) getMergeRequest(ctx context.Context, number int) (*mergeRequest, error) {
+	var mr mergeRequest
+	if _, err := c.do(ctx, http.MethodGet, c.projectPath("/merge_requests/%d", number), nil, nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// GetPullRequests lists merge requests. A head in "owner:branch" form is
+// matched on the branch name only.
+func (c *Client) GetPullRequests(ctx context.Context, input gh.GetPullRequestsInput) ([]*gh.PullRequest, error) {
+	query := url.Values{"per_page": {"100"}}
+	switch input.State {
+	case "", "open":
+		query.Set("state", "opened")
+	case "closed", "merged", "all":
+		query.Set("state", input.State)
+	default:
+		return nil, fmt.Errorf("unsupported merge request state %q", input.State)
+	}
+	if input.Head != "" {
+		_, branch, found := strings.Cut(input.Head, ":")
+		if !found {
+			branch = input.Head
+		}
+		query.Set("source_branch", branch)
+	}
+	if input.Base != "" {
+		query.Set("target_branch", input.Base)
+	}
+	switch input.Sort {
+	case "created":
+		query.Set("order_by", "created_at")
+	case "updated":
+		query.Set("order_by", "updated_at")
+	}
+	if input.Dir != "" {
+		query.Set("sort", input.Dir)
+	}
+
+	mrs, err := c.listMergeRequests(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+
+	result := make([]*gh.PullRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		result = append(result, convertToPullRequest(mr))
+	}
+	return result, nil
+}
+
+func (c *Client) listMergeRequests(ctx context.Context, query url.Values) ([]*mergeRequest, error) {
+	var result []*mergeRequest
+	for {
+		var page []*mergeRequest
+		next, err := c.do(ctx, http.MethodGet, c.projectPath("/merge_requests"), query, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+
+		if next == 0 {
+			break
+		}
+		query.Set("page", strconv.Itoa(next))
+	}
+	return result, nil
+}
+
+// CreatePullRequest opens a merge request from head into base. Stacked merge
+// requests target their parent branch, so base is usually not the trunk.
+func (c *Client) CreatePullRequest(ctx context.Context, title, body, head, base string, draft bool) (*gh.PullRequest, error) {
+	if draft {
+		title = setDraft(title, true)
+	}
+
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodPost, c.projectPath("/merge_requests"), nil, map[string]any{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge request: %w", err)
+	}
+
+	return convertToPullRequest(&mr), nil
+}
+
+// UpdatePullRequest updates an existing merge request. State is "open" or
+// "closed", as on GitHub.
+func (c *Client) UpdatePullRequest(ctx context.Context, number int, title, body *string, state *string) (*gh.PullRequest, error) {
+	update := map[string]any{}
+	if title != nil {
+		update["title"] = *title
+	}
+	if body != nil {
+		update["description"] = *body
+	}
+	if state != nil {
+		switch *state {
+		case "open":
+			update["state_event"] = "reopen"
+		case "closed":
+			update["state_event"] = "close"
+		default:
+			return nil, fmt.Errorf("unsupported merge request state %q", *state)
+		}
+	}
+
+	return c.updateMergeRequest(ctx, number, update, "failed to update merge request")
+}
+
+// RetargetPullRequest changes the target branch of a merge request.
+func (c *Client) RetargetPullRequest(ctx context.Context, number int, base string) (*gh.PullRequest, error) {
+	return c.updateMergeRequest(ctx, number, map[string]any{"target_branch": base},
+		fmt.Sprintf("failed to change target of merge request to %s", base))
+}
+
+// RequestReviewers adds reviewers to a merge request, keeping the ones
+// already assigned. GitLab has no team reviewers, so "group/team" entries
+// are rejected.
+func (c *Client) RequestReviewers(ctx context.Context, number int, reviewers []string) (*gh.PullRequest, error) {
+	mr, err := c.getMergeRequest(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request reviews: %w", err)
+	}
+
+	ids := make([]int, 0, len(mr.Reviewers)+len(reviewers))
+	for _, existing := range mr.Reviewers {
+		ids = append(ids, existing.ID)
+	}
+	for _, reviewer := range reviewers {
+		if strings.Contains(reviewer, "/") {
+			return nil, fmt.Errorf("failed to request reviews: GitLab does not support team reviewer %q", reviewer)
+		}
+		u, err := c.findUser(ctx, reviewer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request reviews: %w", err)
+		}
+		ids = append(ids, u.ID)
+	}
+
+	return c.updateMergeRequest(ctx, number, map[string]any{"reviewer_ids": ids}, "failed to request reviews")
+}
+
+// IsBranchMerged checks if a branch is merged by looking for merged merge requests
+func (c *Client) IsBranchMerged(ctx context.Context, branchName string) (bool, error) {
+	var mrs []*mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.projectPath("/merge_requests"), url.Values{
+		"state":         {"merged"},
+		"source_branch": {branchName},
+		"per_page":      {"1"},
+	}, nil, &mrs)
+	if err != nil {
+		return false, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+	return len(mrs) > 0, nil
+}
+
+func (c *Client) updateMergeRequest(ctx context.Context, number int, update map[string]any, failure string) (*gh.PullRequest, error) {
+	var mr mergeRequest
+	if _, err := c.do(ctx, http.MethodPut, c.projectPath("/merge_requests/%d", number), nil, update, &mr); err != nil {
+		return nil, fmt.Errorf("%s: %w", failure, err)
+	}
+	return convertToPullRequest(&mr), nil
+}
+
+func (c *Client) findUser(ctx context.Context, username string) (*user, error) {
+	username = strings.TrimPrefix(username, "@")
+	var users []*user
+	if _, err := c.do(ctx, http.MethodGet, "users", url.Values{"username": {username}}, nil, &users); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user %q not found", username)
+	}
+	return users[0], nil
+}
+
+// setDraft adds or removes the draft prefix of a merge request title.
+func setDraft(title string, draft bool) string {
+	title = draftRegex.ReplaceAllString(title, "")
+	if draft {
+		return draftPrefix + title
+	}
+	return title
+}
+
+// convertToPullRequest converts a GitLab merge request to a Stacked pull
+// request. GitLab's "opened" state becomes "open", matching GitHub.
+func convertToPullRequest(mr *mergeRequest) *gh.PullRequest {
+	state := mr.State
+	switch state {
+	case "opened", "locked":
+		state = "open"
+	}
+
+	mergeCommit := mr.MergeCommitSHA
+	if mergeCommit == "" {
+		mergeCommit = mr.SquashCommitSHA
+	}
+
+	return &gh.PullRequest{
+		ID:             strconv.Itoa(mr.ID),
+		Number:         mr.IID,
+		HeadRefName:    mr.SourceBranch,
+		HeadSHA:        mr.SHA,
+		BaseRefName:    mr.TargetBranch,
+		IsDraft:        mr.Draft || mr.WorkInProgress,
+		Permalink:      mr.WebURL,
+		State:          state,
+		Title:          mr.Title,
+		Body:           mr.Description,
+		MergeCommit:    mergeCommit,
+		MergeableState: mergeableState(mr.DetailedMergeStatus),
+		AutoMerge:      mr.MergeWhenPipelineSucceeds,
+	}
+}
+
+// mergeableState maps GitLab's detailed merge status onto GitHub's
+// mergeable_state values used by gh.PullRequest.
+func mergeableState(status string) string {
+	switch status {
+	case "mergeable":
+		return "clean"
+	case "conflict", "need_rebase":
+		return "dirty"
+	case "draft_status":
+		return "draft"
+	case "", "checking", "unchecked", "preparing", "approvals_syncing":
+		return "unknown"
+	}
+	return "blocked"
+}
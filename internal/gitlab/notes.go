@@ -0,0 +1,81 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"zip/internal/gh"
+)
+
+type note struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+}
+
+// FindStackComment searches for the stack note in a merge request
+func (c *Client) FindStackComment(ctx context.Context, number int) (*gh.Comment, error) {
+	query := url.Values{"per_page": {"100"}}
+	for {
+		var notes []*note
+		next, err := c.do(ctx, http.MethodGet, c.projectPath("/merge_requests/%d/notes", number), query, nil, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list notes: %w", err)
+		}
+
+		for _, n := range notes {
+			if n.System {
+				continue
+			}
+			if comment := c.convertToComment(number, n); comment.IsStackComment() {
+				return comment, nil
+			}
+		}
+
+		if next == 0 {
+			break
+		}
+		query.Set("page", strconv.Itoa(next))
+	}
+
+	return nil, nil // Note not found
+}
+
+// AddComment adds a new note to a merge request
+func (c *Client) AddComment(ctx context.Context, number int, body string) (*gh.Comment, error) {
+	var n note
+	_, err := c.do(ctx, http.MethodPost, c.projectPath("/merge_requests/%d/notes", number), nil, map[string]any{"body": body}, &n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+	return c.convertToComment(number, &n), nil
+}
+
+// UpdateComment replaces the body of an existing note
+func (c *Client) UpdateComment(ctx context.Context, number int, commentID int64, body string) (*gh.Comment, error) {
+	var n note
+	_, err := c.do(ctx, http.MethodPut, c.projectPath("/merge_requests/%d/notes/%d", number, commentID), nil, map[string]any{"body": body}, &n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	return c.convertToComment(number, &n), nil
+}
+
+// RemoveComment removes a note from a merge request
+func (c *Client) RemoveComment(ctx context.Context, number int, commentID int64) error {
+	_, err := c.do(ctx, http.MethodDelete, c.projectPath("/merge_requests/%d/notes/%d", number, commentID), nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) convertToComment(number int, n *note) *gh.Comment {
+	return &gh.Comment{
+		ID:   n.ID,
+		Body: n.Body,
+		URL:  fmt.Sprintf("https://%s/%s/-/merge_requests/%d#note_%d", c.host, c.project, number, n.ID),
+	}
+}
@@ -0,0 +1,131 @@
+// Package record captures the forge API traffic and git invocations of a zip
+// command into an archive, and replays a command from such an archive without
+// network access, so misbehaving runs can be reproduced from a bug report.
+package record
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// archiveVersion is bumped when the archive format changes incompatibly.
+const archiveVersion = 1
+
+// redacted replaces secrets in recorded traffic.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never recorded verbatim.
+var sensitiveHeaders = []string{"Authorization", "Private-Token", "Cookie", "Set-Cookie"}
+
+// Archive is everything recorded for one command.
+type Archive struct {
+	Version   int        `json:"version"`
+	Command   []string   `json:"command"`
+	CreatedAt time.Time  `json:"created_at"`
+	HTTP      []Exchange `json:"http"`
+	Git       []GitCall  `json:"git"`
+}
+
+// Exchange is one HTTP request and its response.
+type Exchange struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+	Error          string      `json:"error,omitempty"`
+	DurationMillis int64       `json:"duration_ms"`
+}
+
+// GitCall is one git invocation and its result.
+type GitCall struct {
+	Args     []string `json:"args"`
+	Stdin    string   `json:"stdin,omitempty"`
+	ExitCode int      `json:"exit_code"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	// Error is set when git couldn't run, or exited non-zero and the
+	// caller asked for an error.
+	Error string `json:"error,omitempty"`
+}
+
+// Load reads an archive written by Recorder.Save.
+func Load(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	var archive Archive
+	if err := json.NewDecoder(zr).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("failed to read recording %s: %w", path, err)
+	}
+	if archive.Version != archiveVersion {
+		return nil, fmt.Errorf("recording %s has version %d, this zip reads version %d", path, archive.Version, archiveVersion)
+	}
+	return &archive, nil
+}
+
+// save writes the archive as gzipped JSON.
+func (a *Archive) save(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(a); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return f.Close()
+}
+
+// redactHeader returns a copy of h with sensitive values replaced.
+func redactHeader(h http.Header, secrets []string) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	for name, values := range out {
+		for i, v := range values {
+			values[i] = redact(v, secrets)
+		}
+		out[name] = values
+	}
+	return out
+}
+
+// redact replaces every occurrence of a secret in s.
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
@@ -0,0 +1,193 @@
+package record
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"zip/internal/config"
+	"zip/internal/gh"
+	"zip/internal/git"
+)
+
+// Recorder captures HTTP traffic and git invocations while a command runs.
+// Install it with ClientOptions and Repo.SetRunHook, then call Save.
+type Recorder struct {
+	mu      sync.Mutex
+	archive Archive
+	secrets []string
+}
+
+var _ git.RunHook = (*Recorder)(nil)
+
+// NewRecorder starts a recording of the given command line.
+func NewRecorder(command []string) *Recorder {
+	return &Recorder{
+		archive: Archive{
+			Version:   archiveVersion,
+			Command:   command,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// Redact makes sure secret never appears in the archive, e.g. the token the
+// client authenticates with.
+func (r *Recorder) Redact(secret string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = append(r.secrets, secret)
+}
+
+// ClientOptions returns opts with the client's HTTP traffic recorded. The
+// token is resolved here, through gh.DefaultTokenChain unless opts.Tokens is
+// set, so it is always redacted. Exchanges are recorded above retries, one
+// per API call, and the response cache is disabled so every call reaches the
+// network and is recorded with its real response.
+func (r *Recorder) ClientOptions(opts gh.Options) (gh.Options, error) {
+	tokens := opts.Tokens
+	if tokens == nil {
+		if opts.Config == nil {
+			cfg, err := config.Load(opts.Dir)
+			if err != nil {
+				return opts, err
+			}
+			opts.Config = cfg
+		}
+		tokens = gh.DefaultTokenChain(opts.Config, opts.Host, opts.Dir)
+	}
+	token, err := tokens.Token()
+	if err != nil {
+		return opts, err
+	}
+	r.Redact(token)
+	opts.Tokens = gh.StaticTokenProvider(token)
+
+	opts.NoCache = true
+	opts.WrapTransport = r.Transport
+	return opts, nil
+}
+
+// Transport returns a RoundTripper that records every exchange made through
+// next, which defaults to http.DefaultTransport.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, next: next}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	exchange := Exchange{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeader:  req.Header,
+		RequestBody:    string(reqBody),
+		DurationMillis: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		exchange.Error = err.Error()
+		t.recorder.addExchange(exchange)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	exchange.StatusCode = resp.StatusCode
+	exchange.ResponseHeader = resp.Header
+	exchange.ResponseBody = string(respBody)
+	t.recorder.addExchange(exchange)
+	return resp, nil
+}
+
+func (r *Recorder) addExchange(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.archive.HTTP = append(r.archive.HTTP, e)
+}
+
+// Run records a git invocation. Interactive invocations are run but their
+// output, which goes to the terminal, isn't captured.
+func (r *Recorder) Run(opts *git.RunOpts, next func(*git.RunOpts) (*git.Output, error)) (*git.Output, error) {
+	call := GitCall{Args: append([]string(nil), opts.Args...)}
+	if opts.Stdin != nil && !opts.Interactive {
+		stdin, err := io.ReadAll(opts.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		call.Stdin = string(stdin)
+		copied := *opts
+		copied.Stdin = strings.NewReader(call.Stdin)
+		opts = &copied
+	}
+
+	out, err := next(opts)
+	if out != nil {
+		call.ExitCode = out.ExitCode
+		call.Stdout = string(out.Stdout)
+		call.Stderr = string(out.Stderr)
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.archive.Git = append(r.archive.Git, call)
+	r.mu.Unlock()
+	return out, err
+}
+
+// Save writes the recording to path with secrets redacted.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	archive := r.archive
+	archive.HTTP = make([]Exchange, len(r.archive.HTTP))
+	for i, e := range r.archive.HTTP {
+		e.URL = redact(e.URL, r.secrets)
+		e.RequestHeader = redactHeader(e.RequestHeader, r.secrets)
+		e.RequestBody = redact(e.RequestBody, r.secrets)
+		e.ResponseHeader = redactHeader(e.ResponseHeader, r.secrets)
+		e.ResponseBody = redact(e.ResponseBody, r.secrets)
+		e.Error = redact(e.Error, r.secrets)
+		archive.HTTP[i] = e
+	}
+	archive.Git = make([]GitCall, len(r.archive.Git))
+	for i, c := range r.archive.Git {
+		c.Args = append([]string(nil), c.Args...)
+		for j, arg := range c.Args {
+			c.Args[j] = redact(arg, r.secrets)
+		}
+		c.Stdin = redact(c.Stdin, r.secrets)
+		c.Stdout = redact(c.Stdout, r.secrets)
+		c.Stderr = redact(c.Stderr, r.secrets)
+		c.Error = redact(c.Error, r.secrets)
+		archive.Git[i] = c
+	}
+	return archive.save(path)
+}
@@ -0,0 +1,166 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"zip/internal/gh"
+	"zip/internal/git"
+)
+
+// ErrNotRecorded is returned when a command makes a request or git call
+// during replay that isn't in the recording.
+var ErrNotRecorded = errors.New("not in the recording")
+
+// Replayer serves recorded HTTP responses and git results in place of the
+// network and git. Identical requests are answered in recorded order.
+type Replayer struct {
+	// Passthrough runs git calls missing from the recording for real instead
+	// of failing, e.g. to replay against a local checkout of the repo.
+	Passthrough bool
+
+	mu       sync.Mutex
+	archive  *Archive
+	usedHTTP []bool
+	usedGit  []bool
+}
+
+var _ git.RunHook = (*Replayer)(nil)
+
+// NewReplayer replays the given archive.
+func NewReplayer(archive *Archive) *Replayer {
+	return &Replayer{
+		archive:  archive,
+		usedHTTP: make([]bool, len(archive.HTTP)),
+		usedGit:  make([]bool, len(archive.Git)),
+	}
+}
+
+// ClientOptions returns opts for a client that is served from the recording.
+// The recording holds the final response of each API call, made above
+// retries and without the response cache, so both are disabled here too. No
+// real token is needed.
+func (p *Replayer) ClientOptions(opts gh.Options) gh.Options {
+	opts.HTTPClient = &http.Client{Transport: p.Transport()}
+	opts.Tokens = gh.StaticTokenProvider(redacted)
+	opts.MaxRetries = -1
+	opts.NoCache = true
+	return opts
+}
+
+// Transport returns a RoundTripper answering from the recording.
+func (p *Replayer) Transport() http.RoundTripper {
+	return replayTransport{p}
+}
+
+type replayTransport struct {
+	replayer *Replayer
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	p := t.replayer
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	url := req.URL.String()
+	i := -1
+	for j, e := range p.archive.HTTP {
+		if !p.usedHTTP[j] && e.Method == req.Method && e.URL == url {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, url, ErrNotRecorded)
+	}
+	p.usedHTTP[i] = true
+
+	e := p.archive.HTTP[i]
+	if e.Error != "" {
+		return nil, replayedNetworkError(e.Error)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.ResponseHeader.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(e.ResponseBody))),
+		ContentLength: int64(len(e.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// replayedNetworkError is a recorded transport failure. It is a net.Error so
+// it is handled like the original, e.g. by queueing operations offline.
+type replayedNetworkError string
+
+func (e replayedNetworkError) Error() string   { return string(e) }
+func (e replayedNetworkError) Timeout() bool   { return false }
+func (e replayedNetworkError) Temporary() bool { return false }
+
+// Run answers a git invocation from the recording.
+func (p *Replayer) Run(opts *git.RunOpts, next func(*git.RunOpts) (*git.Output, error)) (*git.Output, error) {
+	p.mu.Lock()
+	i := -1
+	for j, call := range p.archive.Git {
+		if !p.usedGit[j] && slices.Equal(call.Args, opts.Args) {
+			i = j
+			break
+		}
+	}
+	if i >= 0 {
+		p.usedGit[i] = true
+	}
+	p.mu.Unlock()
+
+	if i < 0 {
+		if p.Passthrough {
+			return next(opts)
+		}
+		return nil, fmt.Errorf("git %s: %w", strings.Join(opts.Args, " "), ErrNotRecorded)
+	}
+
+	call := p.archive.Git[i]
+	if call.Error != "" {
+		return nil, errors.New(call.Error)
+	}
+	out := &git.Output{
+		ExitCode: call.ExitCode,
+		Stdout:   []byte(call.Stdout),
+		Stderr:   []byte(call.Stderr),
+	}
+	if opts.Interactive {
+		// Interactive output went to the terminal and wasn't recorded.
+		out.Stdout, out.Stderr = nil, nil
+	}
+	return out, nil
+}
+
+// Remaining returns how many recorded requests and git calls were not
+// replayed, which hints that the replay diverged from the recording.
+func (p *Replayer) Remaining() (httpRequests, gitCalls int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, used := range p.usedHTTP {
+		if !used {
+			httpRequests++
+		}
+	}
+	for _, used := range p.usedGit {
+		if !used {
+			gitCalls++
+		}
+	}
+	return httpRequests, gitCalls
+}
@@ -0,0 +1,168 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"zip/internal/gh"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// AutoMerge hands merging a stack to the forge. The bottom pull request is
+// added to the merge queue, or gets auto-merge enabled where there is none,
+// and the stack remembers the merge method so that each SyncAutoMerge after
+// it lands promotes the next pull request, until the whole stack has drained.
+// Only opts.Method and opts.Progress are used.
+func AutoMerge(ctx context.Context, repo *git.Repo, db *storage.Database, forge gh.Forge, stackName string, opts MergeOptions) (*MergeResult, error) {
+	method, err := mergeMethod(ctx, repo, forge, opts.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setAutoMerge(db, stackName, method); err != nil {
+		return nil, err
+	}
+
+	return SyncAutoMerge(ctx, repo, db, forge, stackName, opts.Progress)
+}
+
+// SyncAutoMerge advances a stack that is merged by the forge. Merged pull
+// requests are recorded and taken out of the stack, the branch above them is
+// retargeted and restacked onto the trunk, and the lowest open pull request
+// is queued if it isn't already. It returns nil if the stack isn't being
+// merged automatically, and stops auto-merging once the stack is empty.
+func SyncAutoMerge(ctx context.Context, repo *git.Repo, db *storage.Database, forge gh.Forge, stackName string, progress func(string)) (*MergeResult, error) {
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	tx := db.ReadTx()
+	stack, ok := tx.Stack(stackName)
+	if !ok {
+		tx.Close()
+		return nil, fmt.Errorf("stack %s does not exist", stackName)
+	}
+	branches, err := tx.GetOrderedStackBranches(stackName)
+	tx.Close()
+	if err != nil {
+		return nil, err
+	}
+	if stack.AutoMerge == "" {
+		return nil, nil
+	}
+	trunk := stack.BaseBranch
+	result := &MergeResult{Method: stack.AutoMerge}
+
+	oldTips := make(map[string]string)
+
+	for i, branch := range branches {
+		stop := func(reason string) (*MergeResult, error) {
+			result.StoppedAt = branch.Name
+			result.Reason = reason
+			return result, nil
+		}
+
+		if branch.PullRequest == nil {
+			return stop("has no pull request; submit it first")
+		}
+		ref := gh.PullRequestRef{ID: branch.PullRequest.ID, Number: branch.PullRequest.Number}
+
+		pr, err := getPullRequest(ctx, forge, ref)
+		if err != nil {
+			return result, err
+		}
+		if pr.State == "open" && !pr.AutoMerge {
+			if pr.IsDraft {
+				return stop(fmt.Sprintf("#%d is a draft", ref.Number))
+			}
+			// Enabling auto-merge only works against the trunk, and on
+			// GitHub retargeting turns it off again.
+			if pr.BaseBranchName() != trunk {
+				progress(fmt.Sprintf("Changing base of #%d to %s", ref.Number, trunk))
+				if _, err := forge.RetargetPullRequest(ctx, ref.Number, trunk); err != nil {
+					return result, err
+				}
+			}
+
+			progress(fmt.Sprintf("Queueing #%d (%s) to be merged with %s", ref.Number, branch.Name, stack.AutoMerge))
+			if pr, err = forge.EnableAutoMerge(ctx, ref, stack.AutoMerge); err != nil {
+				if gh.IsMergeRefused(err) {
+					return stop(err.Error())
+				}
+				return result, err
+			}
+		}
+		if pr.State == "open" {
+			if err := storePullRequest(db, branch.Name, pr); err != nil {
+				return result, err
+			}
+			result.Queued = branch.Name
+			return result, nil
+		}
+		if pr.State != "merged" {
+			return stop(fmt.Sprintf("#%d is %s", ref.Number, pr.State))
+		}
+
+		if err := recordMerged(db, stackName, branch.Name, pr, trunk); err != nil {
+			return result, err
+		}
+		result.Merged = append(result.Merged, branch.Name)
+
+		if i+1 == len(branches) {
+			break
+		}
+		next := branches[i+1]
+		if reason, err := advanceAfterMerge(ctx, repo, forge, trunk, stack.AutoMerge, branch.Name, next, oldTips, progress); err != nil || reason != "" {
+			if err != nil {
+				return result, err
+			}
+			result.StoppedAt = next.Name
+			result.Reason = reason
+			return result, nil
+		}
+	}
+
+	return result, setAutoMerge(db, stackName, "")
+}
+
+// getPullRequest fetches a pull request in one batch where the forge
+// supports it. On GitHub that goes through GraphQL, which unlike REST reports
+// whether the pull request is in the merge queue.
+func getPullRequest(ctx context.Context, forge gh.Forge, ref gh.PullRequestRef) (*gh.PullRequest, error) {
+	getter, ok := forge.(stackPullRequestGetter)
+	if !ok {
+		return forge.GetPullRequest(ctx, ref.Number)
+	}
+	prs, err := getter.GetStackPullRequests(ctx, []gh.PullRequestRef{ref})
+	if err != nil {
+		return nil, err
+	}
+	return prs[0], nil
+}
+
+// storePullRequest saves the latest state of a branch's pull request.
+func storePullRequest(db *storage.Database, branchName string, pr *gh.PullRequest) error {
+	tx := db.WriteTx()
+	defer tx.Abort()
+	branch, ok := tx.ReadTx.Branch(branchName)
+	if !ok {
+		return fmt.Errorf("branch %s does not exist", branchName)
+	}
+	branch.PullRequest = storage.MakePRData(pr)
+	tx.SetBranch(branch)
+	return tx.Commit()
+}
+
+// setAutoMerge records the method a stack is merged with automatically, or
+// takes it out of auto-merging if method is empty.
+func setAutoMerge(db *storage.Database, stackName string, method gh.MergeMethod) error {
+	tx := db.WriteTx()
+	defer tx.Abort()
+	stack, ok := tx.ReadTx.Stack(stackName)
+	if !ok {
+		return fmt.Errorf("stack %s does not exist", stackName)
+	}
+	stack.AutoMerge = method
+	tx.SetStack(stack)
+	return tx.Commit()
+}
@@ -0,0 +1,108 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"zip/internal/gh"
+	"zip/internal/storage"
+)
+
+func TestSyncAutoMergeDrainsStack(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b", "c"})
+	ctx := context.Background()
+
+	result, err := AutoMerge(ctx, s.repo, s.db, s.forge, "s", MergeOptions{Method: gh.MergeMethodSquash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Queued != "a" {
+		t.Fatalf("queued %q, want a", result.Queued)
+	}
+
+	for i, next := range []string{"b", "c", ""} {
+		merged, err := s.forge.ProcessAutoMerges()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(merged) != 1 || merged[0] != i+1 {
+			t.Fatalf("forge merged %v, want #%d", merged, i+1)
+		}
+
+		if result, err = SyncAutoMerge(ctx, s.repo, s.db, s.forge, "s", nil); err != nil {
+			t.Fatal(err)
+		}
+		if result.StoppedAt != "" {
+			t.Fatalf("sync stopped at %s: %s", result.StoppedAt, result.Reason)
+		}
+		if result.Queued != next {
+			t.Errorf("after #%d merged, queued %q, want %q", i+1, result.Queued, next)
+		}
+		if next != "" {
+			if pr := s.pullRequest(t, i+2); pr.BaseRefName != "main" || !pr.AutoMerge {
+				t.Errorf("#%d has base %s and auto-merge %v, want main and true", pr.Number, pr.BaseRefName, pr.AutoMerge)
+			}
+		}
+	}
+
+	want := []string{"c (#3)", "b (#2)", "a (#1)", "root"}
+	if got := s.remoteLog(t, "main"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("main has commits %q, want %q", got, want)
+	}
+
+	tx := s.reopen(t)
+	stack, _ := tx.Stack("s")
+	if len(stack.Branches) != 0 || stack.AutoMerge != "" {
+		t.Errorf("stack has branches %v and auto-merge %q, want neither", stack.Branches, stack.AutoMerge)
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		branch, _ := tx.Branch(name)
+		if pr := s.pullRequest(t, i+1); branch.MergeCommit == "" || branch.MergeCommit != pr.MergeCommit {
+			t.Errorf("branch %s has merge commit %q, want %q", name, branch.MergeCommit, pr.MergeCommit)
+		}
+	}
+}
+
+func TestSyncAutoMergeStopsAtDraft(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b"}, "b")
+	ctx := context.Background()
+
+	// b's parent was merged and dropped from the stack outside of zip, but
+	// its pull request still targets it.
+	tx := s.db.WriteTx()
+	if err := tx.RemoveBranchFromStack("s", "a"); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := tx.ReadTx.Branch("b")
+	b.Parent = storage.BranchState{Name: "main", Trunk: true}
+	tx.SetBranch(b)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx.Abort()
+
+	result, err := AutoMerge(ctx, s.repo, s.db, s.forge, "s", MergeOptions{Method: gh.MergeMethodSquash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoppedAt != "b" || !strings.Contains(result.Reason, "draft") {
+		t.Errorf("stopped at %q (%q), want b as a draft", result.StoppedAt, result.Reason)
+	}
+	if pr := s.pullRequest(t, 2); pr.BaseRefName != "a" || pr.AutoMerge {
+		t.Errorf("#2 has base %s and auto-merge %v, want it left alone", pr.BaseRefName, pr.AutoMerge)
+	}
+}
+
+func TestSyncAutoMergeReturnsForgeErrors(t *testing.T) {
+	s := newTestStack(t, []string{"a"})
+	s.forge.FailNext("EnableAutoMerge", errOffline)
+
+	result, err := AutoMerge(context.Background(), s.repo, s.db, s.forge, "s", MergeOptions{Method: gh.MergeMethodSquash})
+	if !errors.Is(err, errOffline) {
+		t.Fatalf("AutoMerge returned %v, want the network error", err)
+	}
+	if result.StoppedAt != "" {
+		t.Errorf("stopped at %q (%q), want no stop reason", result.StoppedAt, result.Reason)
+	}
+}
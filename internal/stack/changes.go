@@ -0,0 +1,144 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"zip/internal/gh"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// DefaultChangeBranchPrefix is where single-branch mode pushes the synthetic
+// branch of each change, unless zip.changeBranchPrefix is set.
+const DefaultChangeBranchPrefix = "zip/changes"
+
+// ChangeBranchName returns the synthetic remote branch for a change of a
+// local branch. It only depends on the change ID, so it stays the same when
+// the commit is rebased or amended.
+func ChangeBranchName(repo *git.Repo, branch, changeID string) string {
+	prefix := DefaultChangeBranchPrefix
+	if configured, ok := repo.Config().Get("changebranchprefix"); ok && configured != "" {
+		prefix = configured
+	}
+	return fmt.Sprintf("%s/%s/%s", prefix, branch, changeID[:13])
+}
+
+// SubmitChanges submits a single local branch in Gerrit style: every commit
+// in base..branch becomes its own pull request, stacked on the pull request
+// of the commit before it. Commits without a Zip-Change-Id are given one
+// first. Changes whose commits are no longer on the branch are kept as merged
+// if their pull request landed and have it closed otherwise. It returns the
+// branch's changes, bottom first.
+func SubmitChanges(ctx context.Context, repo *git.Repo, db *storage.Database, forge gh.Forge, branch, base string, draft bool) ([]storage.Change, error) {
+	commits, err := repo.EnsureChangeIDs(base, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := db.WriteTx()
+	defer tx.Abort()
+
+	var submitted []storage.Change
+	parent := base
+	for _, commit := range commits {
+		id := git.ChangeID(commit.Body)
+		change, ok := tx.ReadTx.Change(id)
+		if !ok {
+			change = storage.Change{
+				ID:           id,
+				Branch:       branch,
+				RemoteBranch: ChangeBranchName(repo, branch, id),
+			}
+		}
+
+		if change.Commit != commit.Hash {
+			if err := repo.PushCommit(commit.Hash, change.RemoteBranch, change.Commit); err != nil {
+				return submitted, commitAfter(tx, err)
+			}
+			change.Commit = commit.Hash
+		}
+
+		pr, err := submitChange(ctx, forge, change, commit, parent, draft)
+		if err != nil {
+			tx.SetChange(change)
+			return submitted, commitAfter(tx, fmt.Errorf("change %s (%s): %w", commit.ShortHash, commit.Subject, err))
+		}
+		change.Base = parent
+		change.PullRequest = storage.MakePRData(pr)
+		tx.SetChange(change)
+		submitted = append(submitted, change)
+		parent = change.RemoteBranch
+	}
+
+	for _, stale := range tx.ReadTx.BranchChanges(branch) {
+		if slices.ContainsFunc(submitted, func(c storage.Change) bool { return c.ID == stale.ID }) {
+			continue
+		}
+		if stale.PullRequest == nil {
+			tx.DeleteChange(stale.ID)
+			continue
+		}
+		if stale.PullRequest.State == "merged" {
+			continue
+		}
+
+		// The stored state predates the last sync: a change that landed
+		// drops out of base..branch once the branch is rebased onto trunk.
+		pr, err := forge.GetPullRequest(ctx, stale.PullRequest.Number)
+		if err != nil {
+			return submitted, commitAfter(tx, fmt.Errorf("failed to get pull request of dropped change %s: %w", stale.ID, err))
+		}
+		if pr.State == "merged" {
+			stale.PullRequest = storage.MakePRData(pr)
+			tx.SetChange(stale)
+			continue
+		}
+		if pr.State == "open" {
+			closed := "closed"
+			if _, err := forge.UpdatePullRequest(ctx, pr.Number, nil, nil, &closed); err != nil {
+				return submitted, commitAfter(tx, fmt.Errorf("failed to close pull request of dropped change %s: %w", stale.ID, err))
+			}
+		}
+		tx.DeleteChange(stale.ID)
+	}
+
+	return submitted, tx.Commit()
+}
+
+// submitChange creates the pull request of a change, or brings an existing
+// one up to date with the commit message and base.
+func submitChange(ctx context.Context, forge gh.Forge, change storage.Change, commit *git.CommitInfo, base string, draft bool) (*gh.PullRequest, error) {
+	title := commit.Subject
+	body := git.WithoutChangeID(commit.Body)
+
+	if change.PullRequest == nil {
+		return forge.CreatePullRequest(ctx, title, body, change.RemoteBranch, base, draft)
+	}
+
+	number := change.PullRequest.Number
+	var pr *gh.PullRequest
+	var err error
+	if change.Base != base {
+		if pr, err = forge.RetargetPullRequest(ctx, number, base); err != nil {
+			return nil, err
+		}
+	}
+	if change.PullRequest.Title != title || change.PullRequest.Body != body {
+		if pr, err = forge.UpdatePullRequest(ctx, number, &title, &body, nil); err != nil {
+			return nil, err
+		}
+	}
+	if pr == nil {
+		return forge.GetPullRequest(ctx, number)
+	}
+	return pr, nil
+}
+
+// commitAfter saves what was done before err so a retry continues from there.
+func commitAfter(tx *storage.WriteTx, err error) error {
+	if commitErr := tx.Commit(); commitErr != nil {
+		return commitErr
+	}
+	return err
+}
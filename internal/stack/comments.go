@@ -0,0 +1,195 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"zip/internal/gh"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// reviewThreadGetter is implemented by forges with line review threads, like
+// gh.Client.
+type reviewThreadGetter interface {
+	GetReviewThreads(ctx context.Context, ref gh.PullRequestRef) ([]*gh.ReviewThread, error)
+}
+
+// ReviewThread is a review thread placed in the local checkout.
+type ReviewThread struct {
+	*gh.ReviewThread
+	// LocalPath and LocalLine locate the commented line at the branch's tip.
+	// LocalLine is 0 when the line was changed or removed since the comment,
+	// when it is on a removed line, or when the reviewed commit can't be
+	// fetched.
+	LocalPath string
+	LocalLine int
+}
+
+// ReviewThreads fetches the review threads of a branch's pull request and
+// follows each commented line from the commit it was made on to the branch's
+// current tip through the diff between them, so the positions survive
+// rebases and amends made since the review.
+func ReviewThreads(ctx context.Context, repo *git.Repo, db *storage.Database, forge gh.Forge, branchName string) ([]ReviewThread, error) {
+	getter, ok := forge.(reviewThreadGetter)
+	if !ok {
+		return nil, fmt.Errorf("review threads are not supported on this forge")
+	}
+
+	ref, err := branchPullRequest(db, branchName)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := getter.GetReviewThreads(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := repo.Git("rev-parse", "--verify", branchName)
+	if err != nil {
+		return nil, err
+	}
+
+	mappers := make(map[string]*git.LineMapper)
+	mapper := func(from string) (*git.LineMapper, error) {
+		if m, ok := mappers[from]; ok {
+			return m, nil
+		}
+		var m *git.LineMapper
+		if ensureCommit(repo, from) {
+			var err error
+			if m, err = repo.NewLineMapper(from, tip); err != nil {
+				return nil, err
+			}
+		}
+		mappers[from] = m
+		return m, nil
+	}
+
+	var head string
+	result := make([]ReviewThread, 0, len(threads))
+	for _, thread := range threads {
+		local := ReviewThread{ReviewThread: thread, LocalPath: thread.Path}
+		if thread.Side == "LEFT" {
+			result = append(result, local)
+			continue
+		}
+
+		from, line := thread.OriginalCommit, thread.OriginalLine
+		m, err := mapper(from)
+		if err != nil {
+			return nil, err
+		}
+		if (m == nil || from == "") && thread.Line != 0 {
+			// The reviewed commit is gone, e.g. after a force-push, but
+			// GitHub still places the thread in the pull request's head.
+			if head == "" {
+				pr, err := forge.GetPullRequest(ctx, ref.Number)
+				if err != nil {
+					return nil, err
+				}
+				head = pr.HeadSHA
+			}
+			from, line = head, thread.Line
+			if m, err = mapper(from); err != nil {
+				return nil, err
+			}
+		}
+		if m != nil && line != 0 {
+			local.LocalPath, local.LocalLine = m.MapLine(thread.Path, line)
+		}
+		result = append(result, local)
+	}
+	return result, nil
+}
+
+// reviewThreadEditor is implemented by forges that can reply to and resolve
+// review threads, like gh.Client.
+type reviewThreadEditor interface {
+	reviewThreadGetter
+	ReplyToReviewComment(ctx context.Context, number int, commentID int64, body string) (*gh.ReviewComment, error)
+	ResolveReviewThread(ctx context.Context, threadID string) (*gh.ReviewThread, error)
+	UnresolveReviewThread(ctx context.Context, threadID string) (*gh.ReviewThread, error)
+}
+
+var _ reviewThreadEditor = (*gh.Client)(nil)
+
+// ReplyToReviewThread replies to the review thread of a branch's pull request
+// that contains the comment with the given ID, as listed by the comments
+// view.
+func ReplyToReviewThread(ctx context.Context, db *storage.Database, forge gh.Forge, branchName string, commentID int64, body string) (*gh.ReviewComment, error) {
+	editor, ref, thread, err := findReviewThread(ctx, db, forge, branchName, commentID)
+	if err != nil {
+		return nil, err
+	}
+	// GitHub only accepts replies to the comment that started the thread.
+	return editor.ReplyToReviewComment(ctx, ref.Number, thread.Comments[0].DatabaseID, body)
+}
+
+// ResolveReviewThread marks the review thread of a branch's pull request that
+// contains the comment with the given ID as resolved or unresolved.
+func ResolveReviewThread(ctx context.Context, db *storage.Database, forge gh.Forge, branchName string, commentID int64, resolved bool) (*gh.ReviewThread, error) {
+	editor, _, thread, err := findReviewThread(ctx, db, forge, branchName, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.IsResolved == resolved {
+		return thread, nil
+	}
+	if resolved {
+		return editor.ResolveReviewThread(ctx, thread.ID)
+	}
+	return editor.UnresolveReviewThread(ctx, thread.ID)
+}
+
+// findReviewThread finds the thread containing a comment on a branch's pull
+// request.
+func findReviewThread(ctx context.Context, db *storage.Database, forge gh.Forge, branchName string, commentID int64) (reviewThreadEditor, gh.PullRequestRef, *gh.ReviewThread, error) {
+	editor, ok := forge.(reviewThreadEditor)
+	if !ok {
+		return nil, gh.PullRequestRef{}, nil, fmt.Errorf("review threads are not supported on this forge")
+	}
+	ref, err := branchPullRequest(db, branchName)
+	if err != nil {
+		return nil, ref, nil, err
+	}
+	threads, err := editor.GetReviewThreads(ctx, ref)
+	if err != nil {
+		return nil, ref, nil, err
+	}
+	for _, thread := range threads {
+		for _, comment := range thread.Comments {
+			if comment.DatabaseID == commentID {
+				return editor, ref, thread, nil
+			}
+		}
+	}
+	return nil, ref, nil, fmt.Errorf("no review comment %d on pull request #%d", commentID, ref.Number)
+}
+
+// branchPullRequest returns the pull request of a branch.
+func branchPullRequest(db *storage.Database, branchName string) (gh.PullRequestRef, error) {
+	tx := db.ReadTx()
+	branch, ok := tx.Branch(branchName)
+	tx.Close()
+	if !ok {
+		return gh.PullRequestRef{}, fmt.Errorf("branch %s does not exist", branchName)
+	}
+	if branch.PullRequest == nil {
+		return gh.PullRequestRef{}, fmt.Errorf("branch %s has no pull request; submit it first", branchName)
+	}
+	return gh.PullRequestRef{ID: branch.PullRequest.ID, Number: branch.PullRequest.Number}, nil
+}
+
+// ensureCommit makes sure a commit is available locally, fetching it by its
+// SHA if needed, and reports whether it is.
+func ensureCommit(repo *git.Repo, sha string) bool {
+	if sha == "" {
+		return false
+	}
+	if repo.HasCommit(sha) {
+		return true
+	}
+	if _, err := repo.Git("fetch", repo.GetRemoteName(), sha); err != nil {
+		return false
+	}
+	return repo.HasCommit(sha)
+}
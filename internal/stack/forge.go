@@ -0,0 +1,100 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+	"zip/internal/gh"
+	"zip/internal/git"
+	"zip/internal/gitea"
+	"zip/internal/gitlab"
+)
+
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea"
+	// ForgeForgejo is an alias of ForgeGitea; Forgejo serves the Gitea API.
+	ForgeForgejo = "forgejo"
+)
+
+// ForgeKind returns which forge hosts the repository: zip.<host>.forge or
+// zip.forge if set, otherwise guessed from the host name.
+func ForgeKind(repo *git.Repo, host string) string {
+	if kind, ok := repo.Config().HostValue(host, "forge"); ok && kind != "" {
+		return strings.ToLower(kind)
+	}
+	host = strings.ToLower(host)
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return ForgeGitLab
+	case strings.Contains(host, "gitea"):
+		return ForgeGitea
+	case strings.Contains(host, "forgejo"), host == "codeberg.org":
+		return ForgeForgejo
+	}
+	return ForgeGitHub
+}
+
+// ForgeOptions configures the client OpenForge returns.
+type ForgeOptions struct {
+	// NoCache skips the on-disk response cache, for --no-cache. Only the
+	// GitHub client caches responses.
+	NoCache bool
+}
+
+// OpenForge returns a client for the forge pull requests are opened on.
+func OpenForge(repo *git.Repo, opts ForgeOptions) (gh.Forge, error) {
+	origin, err := repo.GetOrigin()
+	if err != nil {
+		return nil, err
+	}
+	owner, name, err := origin.OwnerAndName()
+	if err != nil {
+		return nil, err
+	}
+	host := origin.Host()
+
+	switch kind := ForgeKind(repo, host); kind {
+	case ForgeGitHub:
+		headOwner := owner
+		if repo.IsForkWorkflow() {
+			if headOwner, _, err = repo.PushOwnerAndName(); err != nil {
+				return nil, err
+			}
+		}
+		return gh.Shared(gh.Options{
+			Owner:     owner,
+			Repo:      name,
+			HeadOwner: headOwner,
+			Host:      host,
+			Config:    repo.Config(),
+			Dir:       repo.Dir(),
+			CacheDir:  gh.CacheDir(repo.ZipDir()),
+			NoCache:   opts.NoCache,
+		})
+	case ForgeGitLab:
+		if repo.IsForkWorkflow() {
+			return nil, fmt.Errorf("merge requests from forks are not supported on GitLab yet")
+		}
+		return gitlab.New(gitlab.Options{
+			Project: origin.RepoSlug,
+			Host:    host,
+			Config:  repo.Config(),
+			Dir:     repo.Dir(),
+		})
+	case ForgeGitea, ForgeForgejo:
+		if repo.IsForkWorkflow() {
+			return nil, fmt.Errorf("pull requests from forks are not supported on %s yet", kind)
+		}
+		return gitea.New(gitea.Options{
+			Owner:  owner,
+			Repo:   name,
+			Host:   host,
+			Config: repo.Config(),
+			Dir:    repo.Dir(),
+		})
+	default:
+		return nil, fmt.Errorf("unknown forge %q for %s, expected one of %q, %q, %q or %q",
+			kind, host, ForgeGitHub, ForgeGitLab, ForgeGitea, ForgeForgejo)
+	}
+}
@@ -0,0 +1,74 @@
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"zip/internal/storage"
+)
+
+const (
+	dependenciesStart = "<!-- zip:dependencies -->"
+	dependenciesEnd   = "<!-- /zip:dependencies -->"
+)
+
+var dependenciesRegex = regexp.MustCompile(`(?s)\n*` + regexp.QuoteMeta(dependenciesStart) + `.*?` + regexp.QuoteMeta(dependenciesEnd) + `\n*`)
+
+// PullRequestBase returns the base branch for a branch's pull request. On a
+// fork, parent branches only exist on the fork and can't be used as a base
+// on the upstream repo, so every pull request targets the trunk and its
+// ancestors are described in the body with WithDependencies instead.
+func PullRequestBase(tx *storage.ReadTx, branch storage.Branch, fork bool) (string, error) {
+	if !fork || branch.Parent.Trunk {
+		return branch.Parent.Name, nil
+	}
+
+	heritage, err := tx.GetHeritage(branch.Name)
+	if err != nil {
+		return "", err
+	}
+	return heritage[len(heritage)-1].Name, nil
+}
+
+// Dependencies returns the ancestors of a branch below it in the stack,
+// bottom first, excluding the trunk.
+func Dependencies(tx *storage.ReadTx, branch storage.Branch) ([]storage.Branch, error) {
+	heritage, err := tx.GetHeritage(branch.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []storage.Branch
+	// heritage is [branch, parent, ..., trunk]; skip both ends.
+	for i := len(heritage) - 2; i > 0; i-- {
+		deps = append(deps, heritage[i])
+	}
+	return deps, nil
+}
+
+// WithDependencies replaces the dependency section of a pull request body,
+// adding one if needed. Without dependencies the section is removed.
+func WithDependencies(body string, deps []storage.Branch) string {
+	body = strings.TrimRight(dependenciesRegex.ReplaceAllString(body, "\n\n"), "\n")
+	if len(deps) == 0 {
+		return body
+	}
+
+	var section strings.Builder
+	section.WriteString(dependenciesStart)
+	section.WriteString("\n**Depends on:**\n")
+	for _, dep := range deps {
+		if dep.PullRequest != nil {
+			section.WriteString(fmt.Sprintf("- #%d (`%s`)\n", dep.PullRequest.Number, dep.Name))
+		} else {
+			section.WriteString(fmt.Sprintf("- `%s` (not submitted yet)\n", dep.Name))
+		}
+	}
+	section.WriteString("\nThis pull request includes the commits of the pull requests above; merge those first.\n")
+	section.WriteString(dependenciesEnd)
+
+	if body == "" {
+		return section.String()
+	}
+	return body + "\n\n" + section.String()
+}
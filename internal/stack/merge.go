@@ -0,0 +1,303 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"zip/internal/gh"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+const (
+	defaultMergePollInterval = 3 * time.Second
+	defaultMergeTimeout      = 5 * time.Minute
+)
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Method overrides the merge method. By default zip.mergeMethod is used
+	// if set, otherwise the first method the repository allows.
+	Method gh.MergeMethod
+	// PollInterval and Timeout control waiting for the forge to compute
+	// mergeability and to report a pull request as merged.
+	PollInterval time.Duration
+	Timeout      time.Duration
+	// Progress, if set, is called with a line describing each step.
+	Progress func(string)
+}
+
+// MergeResult reports how far Merge got.
+type MergeResult struct {
+	Method gh.MergeMethod
+	// Merged lists the branches merged, bottom first.
+	Merged []string
+	// StoppedAt is the branch Merge couldn't merge, or "" if the whole
+	// stack was merged. Reason explains why.
+	StoppedAt string
+	Reason    string
+	// Queued is the branch whose pull request the forge will merge next
+	// when the stack is merged automatically.
+	Queued string
+}
+
+// Merge merges a stack bottom-up. Each pull request is merged into the trunk
+// once the forge reports it mergeable, then the next pull request is
+// retargeted onto the trunk and, unless merge commits are used, its branch
+// is rebased onto the updated trunk and force-pushed. Merge stops at the
+// first pull request that can't be merged, e.g. because of failing checks or
+// missing approvals, and reports it in the result rather than as an error.
+func Merge(ctx context.Context, repo *git.Repo, db *storage.Database, forge gh.Forge, stackName string, opts MergeOptions) (*MergeResult, error) {
+	if opts.PollInterval == 0 {
+		opts.PollInterval = defaultMergePollInterval
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultMergeTimeout
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	method, err := mergeMethod(ctx, repo, forge, opts.Method)
+	if err != nil {
+		return nil, err
+	}
+	result := &MergeResult{Method: method}
+
+	tx := db.ReadTx()
+	stack, ok := tx.Stack(stackName)
+	if !ok {
+		tx.Close()
+		return nil, fmt.Errorf("stack %s does not exist", stackName)
+	}
+	branches, err := tx.GetOrderedStackBranches(stackName)
+	tx.Close()
+	if err != nil {
+		return nil, err
+	}
+	trunk := stack.BaseBranch
+
+	originalBranch, _ := repo.CurrentBranch()
+	defer func() {
+		if originalBranch != "" && !slices.Contains(result.Merged, originalBranch) {
+			_, _ = repo.Switch(&git.SwitchOpts{Name: originalBranch})
+		}
+	}()
+
+	oldTips := make(map[string]string)
+
+	for i, branch := range branches {
+		stop := func(reason string) (*MergeResult, error) {
+			result.StoppedAt = branch.Name
+			result.Reason = reason
+			return result, nil
+		}
+
+		if branch.PullRequest == nil {
+			return stop("has no pull request; submit it first")
+		}
+		number := branch.PullRequest.Number
+
+		pr, err := forge.GetPullRequest(ctx, number)
+		if err != nil {
+			return result, err
+		}
+		if pr.State != "merged" {
+			if pr.BaseBranchName() != trunk {
+				progress(fmt.Sprintf("Changing base of #%d to %s", number, trunk))
+				if _, err := forge.RetargetPullRequest(ctx, number, trunk); err != nil {
+					return result, err
+				}
+			}
+
+			progress(fmt.Sprintf("Waiting for #%d to be mergeable", number))
+			if pr, err = waitForPullRequest(ctx, forge, number, opts, (*gh.PullRequest).IsMergeableStateKnown); err != nil {
+				return result, err
+			}
+			if !pr.IsMergeableStateKnown() {
+				return stop(fmt.Sprintf("#%d wasn't reported as mergeable or not within %s", number, opts.Timeout))
+			}
+			if blocker := pr.MergeBlocker(); blocker != "" {
+				return stop(fmt.Sprintf("#%d %s", number, blocker))
+			}
+
+			progress(fmt.Sprintf("Merging #%d (%s) with %s", number, branch.Name, method))
+			if _, err := forge.MergePullRequest(ctx, number, method, pr.HeadSHA); err != nil {
+				if gh.IsMergeRefused(err) {
+					return stop(err.Error())
+				}
+				return result, err
+			}
+			pr, err = waitForPullRequest(ctx, forge, number, opts, func(pr *gh.PullRequest) bool {
+				return pr.State == "merged"
+			})
+			if err != nil {
+				return result, err
+			}
+			if pr.State != "merged" {
+				return stop(fmt.Sprintf("#%d was not reported as merged within %s", number, opts.Timeout))
+			}
+		}
+
+		if err := recordMerged(db, stackName, branch.Name, pr, trunk); err != nil {
+			return result, err
+		}
+		result.Merged = append(result.Merged, branch.Name)
+
+		if i+1 == len(branches) {
+			break
+		}
+		next := branches[i+1]
+		if reason, err := advanceAfterMerge(ctx, repo, forge, trunk, method, branch.Name, next, oldTips, progress); err != nil || reason != "" {
+			if err != nil {
+				return result, err
+			}
+			result.StoppedAt = next.Name
+			result.Reason = reason
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// mergeMethod picks the merge method: the requested one, then
+// zip.mergeMethod, then the first one the repository allows.
+func mergeMethod(ctx context.Context, repo *git.Repo, forge gh.Forge, requested gh.MergeMethod) (gh.MergeMethod, error) {
+	allowed, err := forge.AllowedMergeMethods(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("the repository doesn't allow any merge method")
+	}
+
+	if requested == "" {
+		if configured, ok := repo.Config().Get("mergemethod"); ok && configured != "" {
+			requested = gh.MergeMethod(strings.ToLower(configured))
+		}
+	}
+	if requested == "" {
+		return allowed[0], nil
+	}
+	if !slices.Contains(allowed, requested) {
+		return "", fmt.Errorf("merge method %q is not allowed in this repository, use one of %v", requested, allowed)
+	}
+	return requested, nil
+}
+
+// waitForPullRequest polls a pull request until done reports true or the
+// timeout passes, and returns its last state. Polls bypass fresh entries of
+// the response cache, which would otherwise hide changes for its TTL.
+func waitForPullRequest(ctx context.Context, forge gh.Forge, number int, opts MergeOptions, done func(*gh.PullRequest) bool) (*gh.PullRequest, error) {
+	deadline := time.Now().Add(opts.Timeout)
+	pollCtx := gh.Revalidate(ctx)
+	for {
+		pr, err := forge.GetPullRequest(pollCtx, number)
+		if err != nil {
+			return nil, err
+		}
+		if done(pr) || time.Now().Add(opts.PollInterval).After(deadline) {
+			return pr, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.PollInterval):
+		}
+	}
+}
+
+// recordMerged stores the merged pull request, takes the branch out of the
+// stack and makes its children children of the trunk.
+func recordMerged(db *storage.Database, stackName string, branchName string, pr *gh.PullRequest, trunk string) error {
+	tx := db.WriteTx()
+	defer tx.Abort()
+
+	branch, ok := tx.ReadTx.Branch(branchName)
+	if !ok {
+		return fmt.Errorf("branch %s does not exist", branchName)
+	}
+	branch.PullRequest = storage.MakePRData(pr)
+	branch.MergeCommit = pr.MergeCommit
+	tx.SetBranch(branch)
+	if err := tx.RemoveBranchFromStack(stackName, branch.Name); err != nil {
+		return err
+	}
+
+	for _, child := range tx.ReadTx.ChildrenBranches(branch.Name) {
+		child.Parent = storage.BranchState{Name: trunk, Trunk: true, Head: pr.MergeCommit}
+		tx.SetBranch(child)
+	}
+	return tx.Commit()
+}
+
+// advanceAfterMerge prepares next, the branch above the just merged one, to
+// be merged: its pull request is retargeted onto the trunk and, unless merge
+// commits are used, it is rebased onto the updated trunk and force-pushed.
+// oldTips holds where restacked branches pointed before, since their children
+// still build on the old commits; it is updated for next. A reason is returned
+// if next couldn't be restacked.
+func advanceAfterMerge(ctx context.Context, repo *git.Repo, forge gh.Forge, trunk string, method gh.MergeMethod, merged string, next storage.Branch, oldTips map[string]string, progress func(string)) (string, error) {
+	if next.PullRequest != nil {
+		// Retarget before anything deletes the merged branch, which would
+		// close the next pull request instead.
+		progress(fmt.Sprintf("Changing base of #%d to %s", next.PullRequest.Number, trunk))
+		if _, err := forge.RetargetPullRequest(ctx, next.PullRequest.Number, trunk); err != nil {
+			return "", err
+		}
+	}
+	if method == gh.MergeMethodMerge {
+		return "", nil
+	}
+
+	upstream, ok := oldTips[merged]
+	if !ok {
+		upstream = merged
+	}
+	tip, err := repo.Git("rev-parse", "--verify", next.Name)
+	if err != nil {
+		return "", err
+	}
+	oldTips[next.Name] = tip
+	progress(fmt.Sprintf("Restacking %s onto %s", next.Name, trunk))
+	return restackOntoTrunk(repo, next.Name, upstream, trunk)
+}
+
+// restackOntoTrunk rebases the commits of branch that aren't in upstream, the
+// merged parent, onto the freshly fetched trunk and force-pushes it. A conflict is
+// aborted and returned as a reason to stop rather than an error.
+func restackOntoTrunk(repo *git.Repo, branch, upstream, trunk string) (string, error) {
+	trunkRef, err := repo.FetchRemoteBranch(trunk)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := repo.Rebase(git.RebaseConfig{
+		Onto:     trunkRef,
+		Upstream: upstream,
+		Branch:   branch,
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.Status == git.RebaseConflict {
+		if _, err := repo.Rebase(git.RebaseConfig{Operation: git.RebaseAbort}); err != nil {
+			return "", err
+		}
+		reason := fmt.Sprintf("conflicts while rebasing %s onto %s; resolve them with zip stack sync and run zip merge again", branch, trunk)
+		if res.ErrorHeadline != "" {
+			reason += ": " + res.ErrorHeadline
+		}
+		return reason, nil
+	}
+
+	if err := repo.PushWithForceWithLease(branch); err != nil {
+		return "", err
+	}
+	return "", nil
+}
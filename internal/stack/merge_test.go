@@ -0,0 +1,186 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"zip/internal/gh"
+	"zip/internal/gh/ghfake"
+)
+
+var testMergeOptions = MergeOptions{PollInterval: time.Millisecond, Timeout: time.Second}
+
+func TestMergeSquash(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b", "c"})
+
+	opts := testMergeOptions
+	opts.Method = gh.MergeMethodSquash
+	result, err := Merge(context.Background(), s.repo, s.db, s.forge, "s", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoppedAt != "" {
+		t.Fatalf("merge stopped at %s: %s", result.StoppedAt, result.Reason)
+	}
+	if got := strings.Join(result.Merged, ","); got != "a,b,c" {
+		t.Errorf("merged %s, want a,b,c", got)
+	}
+
+	// One squashed commit per pull request, each restacked onto the last.
+	want := []string{"c (#3)", "b (#2)", "a (#1)", "root"}
+	if got := s.remoteLog(t, "main"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("main has commits %q, want %q", got, want)
+	}
+
+	tx := s.reopen(t)
+	for i, name := range []string{"a", "b", "c"} {
+		pr := s.pullRequest(t, i+1)
+		if pr.State != "merged" || pr.BaseRefName != "main" {
+			t.Errorf("#%d is %s onto %s, want merged onto main", pr.Number, pr.State, pr.BaseRefName)
+		}
+		branch, ok := tx.Branch(name)
+		if !ok {
+			t.Fatalf("branch %s is gone from storage", name)
+		}
+		if branch.MergeCommit == "" || branch.MergeCommit != pr.MergeCommit {
+			t.Errorf("branch %s has merge commit %q, want %q", name, branch.MergeCommit, pr.MergeCommit)
+		}
+		if branch.PullRequest == nil || branch.PullRequest.State != "merged" {
+			t.Errorf("branch %s has pull request %+v stored, want it merged", name, branch.PullRequest)
+		}
+	}
+	stack, _ := tx.Stack("s")
+	if len(stack.Branches) != 0 {
+		t.Errorf("stack still has branches %v", stack.Branches)
+	}
+}
+
+func TestMergeCommits(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b"})
+
+	opts := testMergeOptions
+	opts.Method = gh.MergeMethodMerge
+	result, err := Merge(context.Background(), s.repo, s.db, s.forge, "s", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoppedAt != "" {
+		t.Fatalf("merge stopped at %s: %s", result.StoppedAt, result.Reason)
+	}
+
+	// Merge commits keep the branches' own commits, so nothing is restacked.
+	want := "Merge pull request #2 from b\nMerge pull request #1 from a\nroot"
+	if got := runGit(t, s.remote, "log", "--first-parent", "--format=%s", "main"); got != want {
+		t.Errorf("main has commits %q, want %q", got, want)
+	}
+	runGit(t, s.work, "fetch", "-q", "origin")
+	runGit(t, s.work, "merge-base", "--is-ancestor", "b", "origin/main")
+	if pr := s.pullRequest(t, 2); pr.BaseRefName != "main" {
+		t.Errorf("#2 has base %s, want main", pr.BaseRefName)
+	}
+}
+
+func TestMergeStopsAtBlockedPullRequest(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b", "c"})
+	if err := s.forge.SetMergeableState(2, "blocked"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := testMergeOptions
+	opts.Method = gh.MergeMethodSquash
+	result, err := Merge(context.Background(), s.repo, s.db, s.forge, "s", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Merged, ","); got != "a" {
+		t.Errorf("merged %s, want a", got)
+	}
+	if result.StoppedAt != "b" || result.Reason == "" {
+		t.Errorf("stopped at %q (%q), want b with a reason", result.StoppedAt, result.Reason)
+	}
+
+	if pr := s.pullRequest(t, 2); pr.State != "open" || pr.BaseRefName != "main" {
+		t.Errorf("#2 is %s onto %s, want open onto main", pr.State, pr.BaseRefName)
+	}
+	if pr := s.pullRequest(t, 3); pr.State != "open" || pr.BaseRefName != "b" {
+		t.Errorf("#3 is %s onto %s, want open onto b", pr.State, pr.BaseRefName)
+	}
+
+	tx := s.reopen(t)
+	stack, _ := tx.Stack("s")
+	if got := strings.Join(stack.Branches, ","); got != "b,c" {
+		t.Errorf("stack has branches %s, want b,c", got)
+	}
+	if b, _ := tx.Branch("b"); !b.Parent.Trunk || b.Parent.Name != "main" {
+		t.Errorf("b has parent %+v, want the trunk", b.Parent)
+	}
+}
+
+func TestMergeReturnsForgeErrors(t *testing.T) {
+	s := newTestStack(t, []string{"a"})
+	offline := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	s.forge.FailNext("MergePullRequest", fmt.Errorf("failed to merge pull request #1: %w", offline))
+
+	result, err := Merge(context.Background(), s.repo, s.db, s.forge, "s", testMergeOptions)
+	if !errors.Is(err, offline) {
+		t.Fatalf("Merge returned %v, want the network error", err)
+	}
+	if len(result.Merged) != 0 || result.StoppedAt != "" {
+		t.Errorf("result %+v, want nothing merged and no stop reason", result)
+	}
+	if pr := s.pullRequest(t, 1); pr.State != "open" {
+		t.Errorf("#1 is %s, want open", pr.State)
+	}
+}
+
+func TestMergeStopsWhileMergeabilityIsUnknown(t *testing.T) {
+	s := newTestStack(t, []string{"a"})
+	if err := s.forge.SetMergeableState(1, "unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Merge(context.Background(), s.repo, s.db, s.forge, "s", testMergeOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoppedAt != "a" || len(result.Merged) != 0 {
+		t.Errorf("result %+v, want a stop at a with nothing merged", result)
+	}
+	if pr := s.pullRequest(t, 1); pr.State != "open" {
+		t.Errorf("#1 is %s, want open", pr.State)
+	}
+}
+
+// pushingForge pushes to a pull request's head branch right before merging
+// it, as if someone pushed after zip checked it.
+type pushingForge struct {
+	*ghfake.Forge
+	s *testStack
+	t *testing.T
+}
+
+func (f *pushingForge) MergePullRequest(ctx context.Context, number int, method gh.MergeMethod, headSHA string) (*gh.PullRequest, error) {
+	f.s.commit(f.t, "late.txt", "late")
+	runGit(f.t, f.s.work, "push", "-q", "origin", "a")
+	return f.Forge.MergePullRequest(ctx, number, method, headSHA)
+}
+
+func TestMergeRefusesMovedHead(t *testing.T) {
+	s := newTestStack(t, []string{"a"})
+
+	forge := &pushingForge{Forge: s.forge, s: s, t: t}
+	result, err := Merge(context.Background(), s.repo, s.db, forge, "s", testMergeOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoppedAt != "a" || !strings.Contains(result.Reason, "modified") {
+		t.Errorf("stopped at %q (%q), want a with its head modified", result.StoppedAt, result.Reason)
+	}
+	if pr := s.pullRequest(t, 1); pr.State != "open" {
+		t.Errorf("#1 is %s, want open", pr.State)
+	}
+}
@@ -0,0 +1,110 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"zip/internal/gh"
+	"zip/internal/storage"
+)
+
+// Do performs a GitHub operation. If GitHub can't be reached, or earlier
+// operations are still waiting, the operation is queued instead so it runs
+// in order on the next Flush. The bool reports whether it was queued.
+func Do(ctx context.Context, db *storage.Database, client gh.Forge, op storage.QueuedOperation) (bool, error) {
+	tx := db.WriteTx()
+	defer tx.Abort()
+
+	if len(tx.ReadTx.PendingOperations()) == 0 {
+		err := apply(ctx, tx, client, op)
+		if err == nil {
+			return false, tx.Commit()
+		}
+		if !gh.IsNetworkError(err) {
+			return false, err
+		}
+	}
+
+	tx.Enqueue(op)
+	return true, tx.Commit()
+}
+
+// Flush replays queued operations in order. It stops at the first failure,
+// leaving that operation and everything after it queued, and returns how
+// many operations were completed.
+func Flush(ctx context.Context, db *storage.Database, client gh.Forge) (int, error) {
+	tx := db.WriteTx()
+	defer tx.Abort()
+
+	done := 0
+	for _, op := range tx.ReadTx.PendingOperations() {
+		if err := apply(ctx, tx, client, op); err != nil {
+			tx.SetOperationError(err.Error())
+			if commitErr := tx.Commit(); commitErr != nil {
+				return done, commitErr
+			}
+			if gh.IsNetworkError(err) {
+				return done, fmt.Errorf("still offline, %s: %w", op, err)
+			}
+			return done, fmt.Errorf("failed to %s: %w", op, err)
+		}
+		// Save after every operation so a later failure or crash never
+		// replays one that already went through.
+		tx.DequeueOperation()
+		if err := tx.Commit(); err != nil {
+			return done, err
+		}
+		done++
+	}
+
+	return done, nil
+}
+
+func apply(ctx context.Context, tx *storage.WriteTx, client gh.Forge, op storage.QueuedOperation) error {
+	branch, ok := tx.ReadTx.Branch(op.Branch)
+	if !ok {
+		return fmt.Errorf("branch %s does not exist", op.Branch)
+	}
+
+	if op.Kind == storage.OpCreatePullRequest {
+		if branch.PullRequest != nil {
+			// Already created, e.g. by an earlier partially applied flush.
+			return nil
+		}
+		pr, err := client.CreatePullRequest(ctx, op.Title, op.Body, op.Branch, op.Base, op.Draft)
+		if err != nil {
+			return err
+		}
+		branch.PullRequest = storage.MakePRData(pr)
+		tx.SetBranch(branch)
+		return nil
+	}
+
+	number := op.Number
+	if number == 0 && branch.PullRequest != nil {
+		number = branch.PullRequest.Number
+	}
+	if number == 0 {
+		return fmt.Errorf("branch %s has no pull request", op.Branch)
+	}
+
+	switch op.Kind {
+	case storage.OpRetargetBase:
+		pr, err := client.RetargetPullRequest(ctx, number, op.Base)
+		if err != nil {
+			return err
+		}
+		branch.PullRequest = storage.MakePRData(pr)
+		tx.SetBranch(branch)
+	case storage.OpUpdateStackComment:
+		if _, err := gh.UpsertStackComment(ctx, client, number, op.Body); err != nil {
+			return err
+		}
+	case storage.OpRequestReviewers:
+		if _, err := client.RequestReviewers(ctx, number, op.Reviewers); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown queued operation %q", op.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"zip/internal/gh"
+	"zip/internal/gh/ghfake"
+	"zip/internal/storage"
+)
+
+var errOffline = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func TestFlushReplaysQueuedOperations(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b"})
+	ctx := context.Background()
+
+	ops := []storage.QueuedOperation{
+		{Kind: storage.OpUpdateStackComment, Branch: "a", Body: gh.StackCommentIdentifier + " old"},
+		{Kind: storage.OpRetargetBase, Branch: "b", Base: "main"},
+		{Kind: storage.OpUpdateStackComment, Branch: "a", Body: gh.StackCommentIdentifier + " new"},
+		{Kind: storage.OpRequestReviewers, Branch: "b", Reviewers: []string{"alice"}},
+	}
+	s.forge.FailNext("FindStackComment", errOffline)
+	for _, op := range ops {
+		queued, err := Do(ctx, s.db, s.forge, op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !queued {
+			t.Fatalf("%s was applied while earlier operations are queued", op)
+		}
+	}
+
+	// The newer stack comment replaces the older one, queued after the
+	// retarget that came in between.
+	var kinds []string
+	for _, op := range s.reopen(t).PendingOperations() {
+		kinds = append(kinds, string(op.Kind)+" "+op.Branch)
+	}
+	want := "retarget_base b,update_stack_comment a,request_reviewers b"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Fatalf("queue is %s, want %s", got, want)
+	}
+
+	done, err := Flush(ctx, s.db, s.forge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 3 {
+		t.Errorf("flushed %d operations, want 3", done)
+	}
+	if pending := s.reopen(t).PendingOperations(); len(pending) != 0 {
+		t.Errorf("still queued after flush: %v", pending)
+	}
+
+	if pr := s.pullRequest(t, 2); pr.BaseRefName != "main" {
+		t.Errorf("#2 has base %s, want main", pr.BaseRefName)
+	}
+	if got := s.forge.Reviewers(2); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("#2 has reviewers %v, want alice", got)
+	}
+	comments := s.forge.Comments(1)
+	if len(comments) != 1 || !strings.HasSuffix(comments[0].Body, " new") {
+		t.Errorf("#1 has comments %+v, want only the new stack comment", comments)
+	}
+}
+
+// onDiskForge records how many operations are still queued on disk whenever
+// reviewers are requested.
+type onDiskForge struct {
+	*ghfake.Forge
+	s       *testStack
+	t       *testing.T
+	pending []int
+}
+
+func (f *onDiskForge) RequestReviewers(ctx context.Context, number int, reviewers []string) (*gh.PullRequest, error) {
+	f.pending = append(f.pending, len(f.s.reopen(f.t).PendingOperations()))
+	return f.Forge.RequestReviewers(ctx, number, reviewers)
+}
+
+func TestFlushSavesEachOperation(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b"})
+	ctx := context.Background()
+
+	s.forge.FailNext("RetargetPullRequest", errOffline)
+	for _, op := range []storage.QueuedOperation{
+		{Kind: storage.OpRetargetBase, Branch: "b", Base: "main"},
+		{Kind: storage.OpRequestReviewers, Branch: "a", Reviewers: []string{"alice"}},
+		{Kind: storage.OpRequestReviewers, Branch: "b", Reviewers: []string{"bob"}},
+	} {
+		if _, err := Do(ctx, s.db, s.forge, op); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	forge := &onDiskForge{Forge: s.forge, s: s, t: t}
+	s.forge.FailNext("RequestReviewers", errOffline)
+	done, err := Flush(ctx, s.db, forge)
+	if err == nil {
+		t.Fatal("Flush succeeded while offline")
+	}
+	if done != 1 {
+		t.Errorf("flushed %d operations, want 1", done)
+	}
+	pending := s.reopen(t).PendingOperations()
+	if len(pending) != 2 || pending[0].Kind != storage.OpRequestReviewers || pending[0].LastError == "" {
+		t.Fatalf("queue after failed flush is %+v, want both reviewer requests with an error on the first", pending)
+	}
+
+	if done, err = Flush(ctx, s.db, forge); err != nil || done != 2 {
+		t.Fatalf("second flush did %d operations, %v, want 2", done, err)
+	}
+
+	// Each completed operation is saved before the next one runs, so a crash
+	// in between never replays it.
+	if got := fmt.Sprint(forge.pending); got != "[2 2 1]" {
+		t.Errorf("operations on disk while requesting reviewers: %s, want [2 2 1]", got)
+	}
+	if pr := s.pullRequest(t, 2); pr.BaseRefName != "main" {
+		t.Errorf("#2 has base %s, want main", pr.BaseRefName)
+	}
+}
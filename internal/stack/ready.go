@@ -0,0 +1,49 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"zip/internal/gh"
+	"zip/internal/storage"
+)
+
+// MarkReady marks the draft pull requests of a stack ready for review, from
+// the bottom of the stack up. If bottom is greater than zero only the bottom
+// N branches are considered. It returns the branches that were marked ready.
+func MarkReady(ctx context.Context, db *storage.Database, client gh.Forge, stackName string, bottom int) ([]string, error) {
+	tx := db.WriteTx()
+	defer tx.Abort()
+
+	branches, err := tx.ReadTx.GetOrderedStackBranches(stackName)
+	if err != nil {
+		return nil, err
+	}
+	if bottom > 0 && bottom < len(branches) {
+		branches = branches[:bottom]
+	}
+
+	var marked []string
+	for _, branch := range branches {
+		if branch.PullRequest == nil || !branch.PullRequest.IsDraft {
+			continue
+		}
+
+		pr, err := client.MarkPullRequestReadyForReview(ctx, gh.PullRequestRef{
+			ID:     branch.PullRequest.ID,
+			Number: branch.PullRequest.Number,
+		})
+		if err != nil {
+			// Keep what was already marked so a retry picks up from here.
+			if commitErr := tx.Commit(); commitErr != nil {
+				return marked, commitErr
+			}
+			return marked, fmt.Errorf("branch %s: %w", branch.Name, err)
+		}
+
+		branch.PullRequest = storage.MakePRData(pr)
+		tx.SetBranch(branch)
+		marked = append(marked, branch.Name)
+	}
+
+	return marked, tx.Commit()
+}
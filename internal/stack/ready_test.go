@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMarkReady(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b", "c"}, "a", "b", "c")
+
+	marked, err := MarkReady(context.Background(), s.db, s.forge, "s", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(marked, ","); got != "a,b" {
+		t.Errorf("marked %s ready, want a,b", got)
+	}
+
+	tx := s.reopen(t)
+	for i, name := range []string{"a", "b", "c"} {
+		wantDraft := name == "c"
+		if pr := s.pullRequest(t, i+1); pr.IsDraft != wantDraft {
+			t.Errorf("#%d is draft %v on the forge, want %v", pr.Number, pr.IsDraft, wantDraft)
+		}
+		if branch, _ := tx.Branch(name); branch.PullRequest.IsDraft != wantDraft {
+			t.Errorf("%s is stored as draft %v, want %v", name, branch.PullRequest.IsDraft, wantDraft)
+		}
+	}
+}
+
+func TestMarkReadyKeepsProgressOnFailure(t *testing.T) {
+	s := newTestStack(t, []string{"a", "b"}, "b")
+	ctx := context.Background()
+
+	// a isn't a draft, so the failure hits b.
+	failure := errors.New("forbidden")
+	s.forge.FailNext("MarkPullRequestReadyForReview", failure)
+	if _, err := MarkReady(ctx, s.db, s.forge, "s", 0); !errors.Is(err, failure) {
+		t.Fatalf("MarkReady returned %v, want the forge's error", err)
+	}
+	if branch, _ := s.reopen(t).Branch("b"); !branch.PullRequest.IsDraft {
+		t.Error("b is stored as ready after marking it failed")
+	}
+
+	marked, err := MarkReady(ctx, s.db, s.forge, "s", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(marked, ","); got != "b" {
+		t.Errorf("marked %s ready, want b", got)
+	}
+	if branch, _ := s.reopen(t).Branch("b"); branch.PullRequest.IsDraft {
+		t.Error("b is still stored as a draft")
+	}
+}
@@ -0,0 +1,39 @@
+package stack
+
+import (
+	"fmt"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// SuggestReviewers maps each branch to the code owners of the files it
+// changes relative to its parent. Branches without owned changes are omitted.
+func SuggestReviewers(repo *git.Repo, branches []storage.Branch) (map[string][]string, error) {
+	owners, err := repo.ReadCodeOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make(map[string][]string)
+	if owners == nil {
+		return suggestions, nil
+	}
+
+	for _, branch := range branches {
+		base := branch.Parent.Head
+		if base == "" {
+			base = branch.Parent.Name
+		}
+
+		files, err := repo.ChangedFiles(base, branch.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest reviewers for %s: %w", branch.Name, err)
+		}
+
+		if reviewers := owners.Reviewers(files); len(reviewers) > 0 {
+			suggestions[branch.Name] = reviewers
+		}
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,142 @@
+package stack
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"zip/internal/gh"
+	"zip/internal/gh/ghfake"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// testStack is a stack of branches with pull requests on a fake forge whose
+// remote is a bare repository in a temporary directory.
+type testStack struct {
+	remote string
+	work   string
+	dbPath string
+	repo   *git.Repo
+	db     *storage.Database
+	forge  *ghfake.Forge
+}
+
+// newTestStack creates a stack named "s" on trunk main with one commit per
+// branch, pushes the branches and opens a pull request for each onto its
+// parent. Branches listed in drafts get draft pull requests.
+func newTestStack(t *testing.T, branches []string, drafts ...string) *testStack {
+	t.Helper()
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "zip")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "zip@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	dir := t.TempDir()
+	s := &testStack{
+		remote: filepath.Join(dir, "remote.git"),
+		work:   filepath.Join(dir, "work"),
+		dbPath: filepath.Join(dir, "db.json"),
+	}
+	runGit(t, dir, "init", "--bare", "-b", "main", s.remote)
+	runGit(t, dir, "clone", s.remote, s.work)
+	s.commit(t, "README", "root")
+	runGit(t, s.work, "push", "-u", "origin", "main")
+
+	var err error
+	if s.db, _, err = storage.OpenDatabase(s.dbPath); err != nil {
+		t.Fatal(err)
+	}
+	s.forge = ghfake.New(s.remote)
+
+	tx := s.db.WriteTx()
+	defer tx.Abort()
+	if _, err := tx.CreateStack("s", "zip", "main", branches); err != nil {
+		t.Fatal(err)
+	}
+	parent := "main"
+	created := time.Now().Add(-time.Hour)
+	for _, name := range branches {
+		runGit(t, s.work, "checkout", "-q", "-b", name)
+		s.commit(t, name+".txt", name)
+		runGit(t, s.work, "push", "-q", "-u", "origin", name)
+
+		pr, err := s.forge.CreatePullRequest(context.Background(), name, "", name, parent, slices.Contains(drafts, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = created.Add(time.Minute)
+		tx.SetBranch(storage.Branch{
+			Name:        name,
+			CreatedDate: created,
+			Parent:      storage.BranchState{Name: parent, Trunk: parent == "main"},
+			PullRequest: storage.MakePRData(pr),
+		})
+		parent = name
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.repo, err = git.OpenRepo(s.work, filepath.Join(s.work, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (s *testStack) commit(t *testing.T, file, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(s.work, file), []byte(content+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, s.work, "add", file)
+	runGit(t, s.work, "commit", "-q", "-m", content)
+}
+
+// remoteLog returns the subjects of the commits on a branch of the remote,
+// newest first.
+func (s *testStack) remoteLog(t *testing.T, branch string) []string {
+	t.Helper()
+	return strings.Split(runGit(t, s.remote, "log", "--format=%s", branch), "\n")
+}
+
+// pullRequest returns the current state of a pull request on the forge.
+func (s *testStack) pullRequest(t *testing.T, number int) *gh.PullRequest {
+	t.Helper()
+	pr, err := s.forge.GetPullRequest(context.Background(), number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pr
+}
+
+// reopen reads the database back from disk, to check what was committed.
+func (s *testStack) reopen(t *testing.T) *storage.ReadTx {
+	t.Helper()
+	db, _, err := storage.OpenDatabase(s.dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := db.ReadTx()
+	t.Cleanup(tx.Close)
+	return tx
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
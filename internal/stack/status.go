@@ -0,0 +1,69 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"zip/internal/gh"
+	"zip/internal/storage"
+)
+
+// stackPullRequestGetter is implemented by forges that fetch a whole stack's
+// pull requests at once, like gh.Client.
+type stackPullRequestGetter interface {
+	GetStackPullRequests(ctx context.Context, refs []gh.PullRequestRef) ([]*gh.PullRequest, error)
+}
+
+// RefreshPullRequests fetches the current state of every pull request in a
+// stack, including CI checks and reviews where the forge reports them, and
+// caches it in storage for the stack view.
+func RefreshPullRequests(ctx context.Context, db *storage.Database, forge gh.Forge, stackName string) error {
+	tx := db.ReadTx()
+	branches, err := tx.GetOrderedStackBranches(stackName)
+	tx.Close()
+	if err != nil {
+		return err
+	}
+
+	refs := storage.StackPRRefs(branches)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	var prs []*gh.PullRequest
+	if getter, ok := forge.(stackPullRequestGetter); ok {
+		if prs, err = getter.GetStackPullRequests(ctx, refs); err != nil {
+			return err
+		}
+	} else {
+		for _, ref := range refs {
+			pr, err := forge.GetPullRequest(ctx, ref.Number)
+			if err != nil {
+				return err
+			}
+			prs = append(prs, pr)
+		}
+	}
+
+	// refs, and so prs, follow the branches that have a pull request.
+	submitted := make([]storage.Branch, 0, len(prs))
+	for _, branch := range branches {
+		if branch.PullRequest != nil {
+			submitted = append(submitted, branch)
+		}
+	}
+
+	wtx := db.WriteTx()
+	defer wtx.Abort()
+	for i, pr := range prs {
+		branch, ok := wtx.ReadTx.Branch(submitted[i].Name)
+		if !ok {
+			return fmt.Errorf("branch %s does not exist", submitted[i].Name)
+		}
+		branch.PullRequest = storage.MakePRData(pr)
+		if pr.State == "merged" {
+			branch.MergeCommit = pr.MergeCommit
+		}
+		wtx.SetBranch(branch)
+	}
+	return wtx.Commit()
+}
@@ -0,0 +1,35 @@
+package storage
+
+import "sort"
+
+// Change returns the change with the given Zip-Change-Id.
+func (tx *ReadTx) Change(id string) (Change, bool) {
+	change, ok := tx.db.state.Changes[id]
+	return change, ok
+}
+
+// BranchChanges returns the changes submitted from a local branch, ordered
+// by remote branch name.
+func (tx *ReadTx) BranchChanges(branch string) []Change {
+	var changes []Change
+	for _, change := range tx.db.state.Changes {
+		if change.Branch == branch {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].RemoteBranch < changes[j].RemoteBranch
+	})
+	return changes
+}
+
+func (tx *WriteTx) SetChange(change Change) {
+	if tx.db.state.Changes == nil {
+		tx.db.state.Changes = make(map[string]Change)
+	}
+	tx.db.state.Changes[change.ID] = change
+}
+
+func (tx *WriteTx) DeleteChange(id string) {
+	delete(tx.db.state.Changes, id)
+}
@@ -20,6 +20,14 @@ type State struct {
 	Repository Repository        `json:"repository"`
 	Branches   map[string]Branch `json:"branches"`
 	Stacks     map[string]Stack  `json:"stacks"`
+	// Submit tracks an in-progress submit so it can resume after failing
+	// part way, e.g. on an exhausted rate limit.
+	Submit *SubmitProgress `json:"submit,omitempty"`
+	// Queue holds GitHub operations recorded while offline, in order.
+	Queue []QueuedOperation `json:"queue,omitempty"`
+	// Changes maps the Zip-Change-Id of each commit submitted in
+	// single-branch mode to its review.
+	Changes map[string]Change `json:"changes,omitempty"`
 }
 
 type Repository struct {
@@ -52,6 +60,30 @@ type PullRequest struct {
 	Body        string `json:"body"`
 	IsDraft     bool   `json:"is_draft"`
 	MergeCommit string `json:"merge_commit"`
+	AutoMerge   bool   `json:"auto_merge,omitempty"`
+	// CheckStatus, ReviewDecision and Approvals cache the CI and review
+	// state as of the last refresh; see gh.PullRequest.
+	CheckStatus    string `json:"check_status,omitempty"`
+	ReviewDecision string `json:"review_decision,omitempty"`
+	Approvals      int    `json:"approvals,omitempty"`
+}
+
+type SubmitProgress struct {
+	Stack     string    `json:"stack"`
+	StartedAt time.Time `json:"started_at"`
+	Completed []string  `json:"completed"`
+}
+
+// Change is a single commit reviewed as its own pull request in
+// single-branch mode. It is pushed to a synthetic RemoteBranch so the pull
+// request survives rebases and amends of the local branch.
+type Change struct {
+	ID           string       `json:"id"`
+	Branch       string       `json:"branch"`
+	RemoteBranch string       `json:"remote_branch"`
+	Commit       string       `json:"commit"`
+	Base         string       `json:"base"`
+	PullRequest  *PullRequest `json:"pull_request,omitempty"`
 }
 
 type Stack struct {
@@ -60,6 +92,9 @@ type Stack struct {
 	CreatedDate time.Time `json:"created_date"`
 	BaseBranch  string    `json:"base_branch"`
 	Branches    []string  `json:"branches"`
+	// AutoMerge is the merge method while the forge is merging the stack
+	// bottom-up through auto-merge or a merge queue.
+	AutoMerge gh.MergeMethod `json:"auto_merge,omitempty"`
 }
 
 func OpenDatabase(path string) (*Database, bool, error) {
@@ -133,5 +168,26 @@ func MakePRData(pr *gh.PullRequest) *PullRequest {
 		Body:        pr.Body,
 		IsDraft:     pr.IsDraft,
 		MergeCommit: pr.MergeCommit,
+		AutoMerge:   pr.AutoMerge,
+
+		CheckStatus:    pr.CheckStatus,
+		ReviewDecision: pr.ReviewDecision,
+		Approvals:      pr.Approvals,
+	}
+}
+
+// StackPRRefs returns references to the pull requests of the given branches,
+// skipping branches that haven't been submitted yet.
+func StackPRRefs(branches []Branch) []gh.PullRequestRef {
+	var refs []gh.PullRequestRef
+	for _, branch := range branches {
+		if branch.PullRequest == nil {
+			continue
+		}
+		refs = append(refs, gh.PullRequestRef{
+			ID:     branch.PullRequest.ID,
+			Number: branch.PullRequest.Number,
+		})
 	}
+	return refs
 }
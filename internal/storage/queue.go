@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+type OperationKind string
+
+const (
+	OpCreatePullRequest  OperationKind = "create_pull_request"
+	OpRetargetBase       OperationKind = "retarget_base"
+	OpUpdateStackComment OperationKind = "update_stack_comment"
+	OpRequestReviewers   OperationKind = "request_reviewers"
+)
+
+// QueuedOperation is a GitHub operation recorded while offline.
+type QueuedOperation struct {
+	Kind   OperationKind `json:"kind"`
+	Branch string        `json:"branch"`
+	// Number is the pull request to act on. It is zero when the pull
+	// request is itself still queued, and resolved from Branch on replay.
+	Number    int       `json:"number,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	Body      string    `json:"body,omitempty"`
+	Base      string    `json:"base,omitempty"`
+	Draft     bool      `json:"draft,omitempty"`
+	Reviewers []string  `json:"reviewers,omitempty"`
+	QueuedAt  time.Time `json:"queued_at"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+func (op QueuedOperation) String() string {
+	switch op.Kind {
+	case OpCreatePullRequest:
+		return fmt.Sprintf("create pull request for %s onto %s", op.Branch, op.Base)
+	case OpRetargetBase:
+		return fmt.Sprintf("change base of %s to %s", op.Branch, op.Base)
+	case OpUpdateStackComment:
+		return fmt.Sprintf("update stack comment on %s", op.Branch)
+	case OpRequestReviewers:
+		return fmt.Sprintf("request reviewers for %s", op.Branch)
+	}
+	return fmt.Sprintf("%s for %s", op.Kind, op.Branch)
+}
+
+// PendingOperations returns the queued operations, oldest first.
+func (tx *ReadTx) PendingOperations() []QueuedOperation {
+	return append([]QueuedOperation(nil), tx.db.state.Queue...)
+}
+
+// Enqueue appends an operation to the offline queue. An update of the stack
+// comment drops an earlier queued one for the same branch, since only the
+// latest body matters. The new one still goes to the end so it runs after
+// the operations queued in between.
+func (tx *WriteTx) Enqueue(op QueuedOperation) {
+	if op.QueuedAt.IsZero() {
+		op.QueuedAt = time.Now()
+	}
+	if op.Kind == OpUpdateStackComment {
+		tx.db.state.Queue = slices.DeleteFunc(tx.db.state.Queue, func(queued QueuedOperation) bool {
+			return queued.Kind == OpUpdateStackComment && queued.Branch == op.Branch
+		})
+	}
+	tx.db.state.Queue = append(tx.db.state.Queue, op)
+}
+
+// DequeueOperation removes the oldest queued operation.
+func (tx *WriteTx) DequeueOperation() {
+	if len(tx.db.state.Queue) > 0 {
+		tx.db.state.Queue = tx.db.state.Queue[1:]
+	}
+}
+
+// SetOperationError records why the oldest queued operation failed to replay.
+func (tx *WriteTx) SetOperationError(message string) {
+	if len(tx.db.state.Queue) > 0 {
+		tx.db.state.Queue[0].LastError = message
+	}
+}
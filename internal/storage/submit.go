@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// SubmitProgress returns the in-progress submit, if any.
+func (tx *ReadTx) SubmitProgress() (SubmitProgress, bool) {
+	if tx.db.state.Submit == nil {
+		return SubmitProgress{}, false
+	}
+	return *tx.db.state.Submit, true
+}
+
+// PendingSubmitBranches returns the branches of the stack that the current
+// submit hasn't finished yet, in stack order. Without a submit in progress
+// for the stack, every branch is pending.
+func (tx *ReadTx) PendingSubmitBranches(stackName string) ([]Branch, error) {
+	branches, err := tx.GetOrderedStackBranches(stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := tx.db.state.Submit
+	if progress == nil || progress.Stack != stackName {
+		return branches, nil
+	}
+
+	var pending []Branch
+	for _, branch := range branches {
+		if !slices.Contains(progress.Completed, branch.Name) {
+			pending = append(pending, branch)
+		}
+	}
+	return pending, nil
+}
+
+// StartSubmit begins tracking a submit of the stack, keeping the progress of
+// an earlier interrupted submit of the same stack.
+func (tx *WriteTx) StartSubmit(stackName string) {
+	if tx.db.state.Submit != nil && tx.db.state.Submit.Stack == stackName {
+		return
+	}
+	tx.db.state.Submit = &SubmitProgress{
+		Stack:     stackName,
+		StartedAt: time.Now(),
+	}
+}
+
+// MarkSubmitted records that a branch has been fully submitted.
+func (tx *WriteTx) MarkSubmitted(branchName string) {
+	if tx.db.state.Submit == nil || slices.Contains(tx.db.state.Submit.Completed, branchName) {
+		return
+	}
+	tx.db.state.Submit.Completed = append(tx.db.state.Submit.Completed, branchName)
+}
+
+// FinishSubmit clears the submit progress once every branch is done.
+func (tx *WriteTx) FinishSubmit() {
+	tx.db.state.Submit = nil
+}
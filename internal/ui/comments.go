@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"zip/internal/stack"
+)
+
+// FormatReviewThreads renders review threads grouped by file and ordered by
+// line, with threads whose line no longer exists locally last in their file.
+// Resolved threads are only listed when showResolved is set.
+func FormatReviewThreads(threads []stack.ReviewThread, showResolved bool) string {
+	byPath := make(map[string][]stack.ReviewThread)
+	unresolved := 0
+	for _, thread := range threads {
+		if !thread.IsResolved {
+			unresolved++
+		} else if !showResolved {
+			continue
+		}
+		byPath[thread.LocalPath] = append(byPath[thread.LocalPath], thread)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%d unresolved of %d review thread(s)\n", unresolved, len(threads)))
+
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		fileThreads := byPath[path]
+		slices.SortStableFunc(fileThreads, func(a, b stack.ReviewThread) int {
+			// Unplaced threads (line 0) go last.
+			if (a.LocalLine == 0) != (b.LocalLine == 0) {
+				if a.LocalLine == 0 {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Compare(a.LocalLine, b.LocalLine)
+		})
+
+		output.WriteString(fmt.Sprintf("\n%s%s%s\n", Bold, path, Reset))
+		for _, thread := range fileThreads {
+			output.WriteString(formatReviewThread(thread))
+		}
+	}
+	return output.String()
+}
+
+func formatReviewThread(thread stack.ReviewThread) string {
+	var output strings.Builder
+
+	location := fmt.Sprintf("line %d", thread.LocalLine)
+	switch {
+	case thread.Side == "LEFT" && thread.Line != 0:
+		// Removed lines aren't mapped onto the local tip, so this is the
+		// line in the base.
+		location = fmt.Sprintf("on removed line %d", thread.Line)
+	case thread.Side == "LEFT":
+		location = "outdated"
+		if thread.OriginalLine != 0 {
+			location = fmt.Sprintf("outdated, was removed line %d", thread.OriginalLine)
+		}
+	case thread.LocalLine == 0:
+		location = "outdated"
+		if thread.OriginalLine != 0 {
+			location = fmt.Sprintf("outdated, was line %d", thread.OriginalLine)
+		}
+	}
+	status := FgYellow + "● unresolved" + Reset
+	if thread.IsResolved {
+		status = FgGreen + "✔ resolved" + Reset
+	}
+	id := ""
+	if len(thread.Comments) > 0 {
+		// Comment IDs identify the thread to reply to or resolve.
+		id = fmt.Sprintf("  %s[%d]%s", Dim, thread.Comments[0].DatabaseID, Reset)
+	}
+	output.WriteString(fmt.Sprintf("  %s%s%s  %s%s\n", FgCyan, location, Reset, status, id))
+
+	for _, comment := range thread.Comments {
+		author := comment.Author
+		if author == "" {
+			author = "ghost"
+		}
+		lines := strings.Split(strings.TrimSpace(comment.Body), "\n")
+		output.WriteString(fmt.Sprintf("    %s%s%s: %s\n", Bold, author, Reset, strings.TrimRight(lines[0], "\r")))
+		for _, line := range lines[1:] {
+			output.WriteString(fmt.Sprintf("      %s\n", strings.TrimRight(line, "\r")))
+		}
+	}
+	return output.String()
+}
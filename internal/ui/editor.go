@@ -0,0 +1,298 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"zip/internal/git"
+	"zip/internal/storage"
+)
+
+// scissorsLine marks the start of the commented context in the editor file.
+// Everything from this line down is discarded. Above it, only git-style
+// comment lines ("#" alone or followed by a space) are stripped; unlike git,
+// lines such as "## Summary" are kept so markdown headings from PR templates
+// survive. A level-one heading is indistinguishable from a comment and is
+// dropped.
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// ErrEmptyPRTitle is returned when the editor is closed without a title.
+var ErrEmptyPRTitle = errors.New("aborting pull request: empty title")
+
+// EditorSeed holds everything used to pre-fill the PR editor.
+type EditorSeed struct {
+	BranchTitle string
+	Template    string
+	// Commits on the branch, newest first as returned by FetchGitLog.
+	Commits []*git.CommitInfo
+	Parent  string
+	// 1-based position of the branch in its stack.
+	StackPosition int
+	StackSize     int
+	DiffStat      string
+	// Reviewers suggested from CODEOWNERS, merged with any entered ones.
+	SuggestedReviewers []string
+}
+
+// NewEditorSeed gathers the editor context for a branch: its commits since
+// its parent, its position in the ordered stack branches and the diffstat
+// against the parent.
+func NewEditorSeed(repo *git.Repo, stackBranches []storage.Branch, branchName, template string, suggestedReviewers []string) (EditorSeed, error) {
+	seed := EditorSeed{
+		BranchTitle:        branchName,
+		Template:           template,
+		StackSize:          len(stackBranches),
+		SuggestedReviewers: suggestedReviewers,
+	}
+
+	index := slices.IndexFunc(stackBranches, func(branch storage.Branch) bool {
+		return branch.Name == branchName
+	})
+	if index < 0 {
+		return seed, fmt.Errorf("branch %s is not part of the stack", branchName)
+	}
+	branch := stackBranches[index]
+	seed.StackPosition = index + 1
+	seed.Parent = branch.Parent.Name
+
+	base := branch.Parent.Head
+	if base == "" {
+		base = branch.Parent.Name
+	}
+
+	commits, err := repo.FetchGitLog(git.LogOptions{
+		RevisionRange:    []string{base + ".." + branchName},
+		SpecificToBranch: true,
+	})
+	if err != nil {
+		return seed, fmt.Errorf("failed to read commits of %s: %w", branchName, err)
+	}
+	seed.Commits = commits
+
+	diff, err := repo.CalculateDiff(git.DiffConfig{
+		Revisions: []string{base, branchName},
+		Stat:      true,
+	})
+	if err != nil {
+		return seed, fmt.Errorf("failed to compute diffstat of %s: %w", branchName, err)
+	}
+	seed.DiffStat = diff.Content
+
+	return seed, nil
+}
+
+// CreatePRInEditor composes the PR title and body in $EDITOR. The first line
+// of the saved file is the title and the rest is the body.
+func CreatePRInEditor(seed EditorSeed) (*PRDetails, error) {
+	details, err := prDetailsFromAnswers()
+	if err != nil {
+		return nil, err
+	}
+
+	title, body := seed.defaults()
+	if details.Title != "" {
+		title = details.Title
+	}
+	if details.Body != "" {
+		body = details.Body
+	}
+
+	details.Reviewers = MergeReviewers(details.Reviewers, seed.SuggestedReviewers)
+
+	if !IsInteractive() {
+		details.Title = title
+		details.Body = body
+		return details, nil
+	}
+
+	file, err := os.CreateTemp("", "zip-PR_EDITMSG-*.md")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PR message file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(seed.render(title, body))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write PR message file: %w", err)
+	}
+
+	if err := runEditor(file.Name()); err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PR message file: %w", err)
+	}
+
+	details.Title, details.Body = parsePRMessage(string(contents))
+	if details.Title == "" {
+		return nil, ErrEmptyPRTitle
+	}
+	return details, nil
+}
+
+// prDetailsFromAnswers builds PR details from the answers supplied up-front.
+func prDetailsFromAnswers() (*PRDetails, error) {
+	title, _ := lookupAnswer(FlagTitle)
+	body, _ := lookupAnswer(FlagBody)
+	labels, _ := lookupAnswer(FlagLabels)
+	reviewers, _ := lookupAnswer(FlagReviewers)
+	assignees, _ := lookupAnswer(FlagAssignees)
+	milestone, _ := lookupAnswer(FlagMilestone)
+
+	var draft bool
+	if value, ok := lookupAnswer(FlagDraft); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for --%s: %w", value, FlagDraft, err)
+		}
+		draft = parsed
+	}
+
+	return &PRDetails{
+		Title:     title,
+		Body:      body,
+		Labels:    splitList(labels),
+		Reviewers: splitList(reviewers),
+		Assignees: splitList(assignees),
+		Milestone: milestone,
+		Draft:     draft,
+	}, nil
+}
+
+// defaults derives the initial title and body from the template and commits.
+func (s EditorSeed) defaults() (string, string) {
+	title := s.BranchTitle
+	var sections []string
+
+	// FetchGitLog returns newest first; PR descriptions read oldest first.
+	commits := make([]*git.CommitInfo, 0, len(s.Commits))
+	for i := len(s.Commits) - 1; i >= 0; i-- {
+		commits = append(commits, s.Commits[i])
+	}
+
+	switch {
+	case len(commits) == 1:
+		title = commits[0].Subject
+		if commits[0].Body != "" {
+			sections = append(sections, commits[0].Body)
+		}
+	case len(commits) > 1:
+		var list strings.Builder
+		for _, commit := range commits {
+			list.WriteString(fmt.Sprintf("- %s\n", commit.Subject))
+			for _, line := range strings.Split(commit.Body, "\n") {
+				if strings.TrimSpace(line) != "" {
+					list.WriteString(fmt.Sprintf("  %s\n", line))
+				}
+			}
+		}
+		sections = append(sections, strings.TrimRight(list.String(), "\n"))
+	}
+
+	if template := strings.TrimSpace(s.Template); template != "" {
+		sections = append(sections, template)
+	}
+
+	return title, strings.Join(sections, "\n\n")
+}
+
+func (s EditorSeed) render(title, body string) string {
+	var out strings.Builder
+	out.WriteString(title)
+	out.WriteString("\n\n")
+	if body != "" {
+		out.WriteString(body)
+		out.WriteString("\n")
+	}
+	out.WriteString("\n")
+	out.WriteString(scissorsLine)
+	out.WriteString("\n")
+	out.WriteString("# Do not modify or remove the line above.\n")
+	out.WriteString("# Everything below it is ignored, as are lines starting with \"# \".\n")
+	out.WriteString("# The first line is the PR title, the rest is the body. An empty\n")
+	out.WriteString("# title aborts the pull request.\n")
+	out.WriteString("#\n")
+	if s.Parent != "" {
+		out.WriteString(fmt.Sprintf("# Parent: %s\n", s.Parent))
+	}
+	if s.StackSize > 0 {
+		out.WriteString(fmt.Sprintf("# Stack position: %d of %d\n", s.StackPosition, s.StackSize))
+	}
+	if len(s.SuggestedReviewers) > 0 {
+		out.WriteString(fmt.Sprintf("# Reviewers: %s\n", strings.Join(s.SuggestedReviewers, ", ")))
+	}
+	if stat := strings.TrimRight(s.DiffStat, "\n"); stat != "" {
+		out.WriteString("#\n# Changes:\n")
+		for _, line := range strings.Split(stat, "\n") {
+			out.WriteString(fmt.Sprintf("#   %s\n", strings.TrimSpace(line)))
+		}
+	}
+	return out.String()
+}
+
+// parsePRMessage splits the saved editor file into a title and body,
+// dropping comment lines and everything from the scissors line down.
+func parsePRMessage(contents string) (string, string) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == scissorsLine {
+			break
+		}
+		if isCommentLine(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	// Skip leading blank lines so the title is the first real line.
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", ""
+	}
+
+	title := strings.TrimSpace(lines[0])
+	body := strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	return title, body
+}
+
+func isCommentLine(line string) bool {
+	return line == "#" || strings.HasPrefix(line, "# ")
+}
+
+// editorCommand resolves the user's editor the same way git does for the
+// environment, falling back to vi.
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+func runEditor(path string) error {
+	editor := editorCommand()
+	// Run through the shell so editors with arguments ("code --wait") work.
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// NonInteractiveEnv forces non-interactive mode when set to a truthy value.
+const NonInteractiveEnv = "ZIP_NON_INTERACTIVE"
+
+// Flag names used to answer the built-in prompts without a terminal.
+const (
+	FlagTitle     = "title"
+	FlagBody      = "body"
+	FlagLabels    = "labels"
+	FlagReviewers = "reviewers"
+	FlagAssignees = "assignees"
+	FlagMilestone = "milestone"
+	FlagDraft     = "draft"
+	FlagTemplate  = "template"
+	FlagUsername  = "git-username"
+	FlagEmail     = "git-email"
+)
+
+var (
+	promptMu       sync.RWMutex
+	nonInteractive bool
+	answers        = map[string]string{}
+)
+
+// MissingAnswerError is returned when a prompt cannot be shown and no answer
+// was supplied for it up-front.
+type MissingAnswerError struct {
+	Prompt string
+	Flag   string
+}
+
+func (e *MissingAnswerError) Error() string {
+	if e.Flag == "" {
+		return fmt.Sprintf("cannot prompt for %q in non-interactive mode", e.Prompt)
+	}
+	return fmt.Sprintf("cannot prompt for %q in non-interactive mode: pass --%s", e.Prompt, e.Flag)
+}
+
+// SetNonInteractive forces (or stops forcing) non-interactive mode.
+func SetNonInteractive(v bool) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	nonInteractive = v
+}
+
+// IsInteractive reports whether prompts and animations may be shown. It is
+// false when forced off, when ZIP_NON_INTERACTIVE or CI is set, or when stdin
+// is not a terminal.
+func IsInteractive() bool {
+	promptMu.RLock()
+	forced := nonInteractive
+	promptMu.RUnlock()
+
+	if forced || envTruthy(NonInteractiveEnv) || envTruthy("CI") {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// SetAnswer records the answer for the prompt bound to the given flag. Callers
+// feed this from command-line flags or config before any prompt runs.
+func SetAnswer(flag, value string) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	answers[flag] = value
+}
+
+func lookupAnswer(flag string) (string, bool) {
+	if flag == "" {
+		return "", false
+	}
+	promptMu.RLock()
+	defer promptMu.RUnlock()
+	value, ok := answers[flag]
+	return value, ok
+}
+
+// PromptOption customises how a single prompt resolves its answer.
+type PromptOption func(*promptConfig)
+
+type promptConfig struct {
+	flag string
+}
+
+// WithFlag binds a prompt to the flag that can answer it non-interactively.
+func WithFlag(name string) PromptOption {
+	return func(c *promptConfig) {
+		c.flag = name
+	}
+}
+
+func newPromptConfig(opts []PromptOption) *promptConfig {
+	cfg := &promptConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+func envTruthy(name string) bool {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		// Any other non-empty value (e.g. CI=yes) counts as set.
+		return true
+	}
+	return b
+}
+
+// splitList splits a comma separated answer, dropping empty entries.
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
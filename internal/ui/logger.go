@@ -5,15 +5,28 @@ import (
 	"strings"
 	"time"
 	"zip/internal/git"
+	"zip/internal/storage"
 )
 
 type Logger struct {
-	logs []git.BranchLog
+	logs         []git.BranchLog
+	pullRequests map[string]*storage.PullRequest
 }
 
 func NewLogger(logs []git.BranchLog) *Logger {
 	return &Logger{
-		logs,
+		logs: logs,
+	}
+}
+
+// SetPullRequests shows the cached pull request status of the given branches
+// next to their logs.
+func (l *Logger) SetPullRequests(branches []storage.Branch) {
+	l.pullRequests = make(map[string]*storage.PullRequest, len(branches))
+	for _, branch := range branches {
+		if branch.PullRequest != nil {
+			l.pullRequests[branch.Name] = branch.PullRequest
+		}
 	}
 }
 
@@ -28,6 +41,11 @@ func (l *Logger) FormatBranchLogs() string {
 			output.WriteString(fmt.Sprintf("◯ %s\n", log.Name))
 		}
 
+		// Pull request, checks and reviews
+		if pr := l.pullRequests[log.Name]; pr != nil {
+			output.WriteString(fmt.Sprintf("│ %s\n", FormatPullRequestStatus(pr)))
+		}
+
 		// Last commit time
 		output.WriteString(fmt.Sprintf("│ %s\n│\n", l.formatTimeSince(log.LastCommit)))
 
@@ -20,11 +20,13 @@ type SpinnerUI struct {
 	useColors bool          // Flag to indicate if colors can be used.
 	checkmark string        // Checkmark symbol (with color if supported).
 	startTime time.Time     // Start time for color cycling.
+	plain     bool          // Render progress as plain log lines instead of redrawing.
 }
 
 // NewSpinnerUI creates a new SpinnerUI with the specified number of lines.
 func NewSpinnerUI(numLines int) *SpinnerUI {
-	useColors := isTerminal() && supportsTrueColor()
+	plain := !isTerminal() || !IsInteractive()
+	useColors := !plain && supportsTrueColor()
 	messages := make([]string, numLines)
 	completed := make([]bool, numLines)
 	for i := 0; i < numLines; i++ {
@@ -45,11 +47,17 @@ func NewSpinnerUI(numLines int) *SpinnerUI {
 		useColors: useColors,
 		checkmark: checkmark,
 		startTime: time.Now(),
+		plain:     plain,
 	}
 }
 
 // Start begins the spinner animation.
 func (ui *SpinnerUI) Start() {
+	if ui.plain {
+		// Plain mode logs each message as it changes; nothing to animate.
+		return
+	}
+
 	// Hide the cursor.
 	fmt.Print("\033[?25l")
 
@@ -69,6 +77,9 @@ func (ui *SpinnerUI) UpdateMessage(pos int, message string) {
 	ui.mu.Lock()
 	defer ui.mu.Unlock()
 	if pos >= 0 && pos < ui.numLines {
+		if ui.plain && ui.messages[pos] != message {
+			fmt.Printf("- %s\n", message)
+		}
 		ui.messages[pos] = message
 	}
 }
@@ -80,6 +91,9 @@ func (ui *SpinnerUI) Complete(pos int, message string) {
 	if pos >= 0 && pos < ui.numLines {
 		ui.messages[pos] = message
 		ui.completed[pos] = true
+		if ui.plain {
+			fmt.Printf("%s %s\n", ui.checkmark, message)
+		}
 		// Check if all spinners are completed.
 		allCompleted := true
 		for _, c := range ui.completed {
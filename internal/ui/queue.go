@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"zip/internal/storage"
+)
+
+// FormatPendingOperations renders the offline queue for status output.
+func FormatPendingOperations(ops []storage.QueuedOperation) string {
+	if len(ops) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s%d pending GitHub operation(s)%s, run `zip submit --flush` once online:\n", FgYellow, len(ops), Reset))
+	for _, op := range ops {
+		output.WriteString(fmt.Sprintf("  • %s", op))
+		if op.LastError != "" {
+			output.WriteString(fmt.Sprintf(" %s(%s)%s", Dim, op.LastError, Reset))
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"zip/internal/storage"
+)
+
+// FormatPullRequestStatus renders a one-line summary of a pull request's
+// state, CI checks and reviews, e.g. "#12 · ✔ checks passed · ✔ 2 approvals".
+func FormatPullRequestStatus(pr *storage.PullRequest) string {
+	parts := []string{fmt.Sprintf("#%d", pr.Number)}
+	switch {
+	case pr.State == "merged":
+		return parts[0] + " " + FgMagenta + "merged" + Reset
+	case pr.State == "closed":
+		return parts[0] + " " + Dim + "closed" + Reset
+	case pr.IsDraft:
+		parts[0] += " " + Dim + "draft" + Reset
+	}
+
+	switch pr.CheckStatus {
+	case "success":
+		parts = append(parts, FgGreen+"✔ checks passed"+Reset)
+	case "failure", "error":
+		parts = append(parts, FgRed+"✘ checks failed"+Reset)
+	case "pending", "expected":
+		parts = append(parts, FgYellow+"● checks pending"+Reset)
+	}
+
+	switch {
+	case pr.ReviewDecision == "changes_requested":
+		parts = append(parts, FgRed+"✘ changes requested"+Reset)
+	case pr.Approvals == 1:
+		parts = append(parts, FgGreen+"✔ 1 approval"+Reset)
+	case pr.Approvals > 1:
+		parts = append(parts, fmt.Sprintf("%s✔ %d approvals%s", FgGreen, pr.Approvals, Reset))
+	case pr.ReviewDecision == "review_required":
+		parts = append(parts, FgYellow+"● review required"+Reset)
+	}
+
+	return strings.Join(parts, " · ")
+}
@@ -2,12 +2,27 @@ package ui
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"zip/internal/git"
 
 	"github.com/charmbracelet/huh"
 )
 
-func Select(options []string, title string) (string, error) {
+func Select(options []string, title string, opts ...PromptOption) (string, error) {
+	cfg := newPromptConfig(opts)
+	if value, ok := lookupAnswer(cfg.flag); ok {
+		for _, option := range options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("invalid value %q for --%s, expected one of: %s", value, cfg.flag, strings.Join(options, ", "))
+	}
+	if !IsInteractive() {
+		return "", &MissingAnswerError{Prompt: title, Flag: cfg.flag}
+	}
+
 	var selectedOption string
 	var optionsList []huh.Option[string]
 
@@ -29,7 +44,15 @@ func Select(options []string, title string) (string, error) {
 	return selectedOption, err
 }
 
-func SingleQuestion(question, placeholder string) (string, error) {
+func SingleQuestion(question, placeholder string, opts ...PromptOption) (string, error) {
+	cfg := newPromptConfig(opts)
+	if value, ok := lookupAnswer(cfg.flag); ok && value != "" {
+		return value, nil
+	}
+	if !IsInteractive() {
+		return "", &MissingAnswerError{Prompt: question, Flag: cfg.flag}
+	}
+
 	theme := huh.ThemeCatppuccin()
 	var answer string
 
@@ -52,9 +75,19 @@ func SingleQuestion(question, placeholder string) (string, error) {
 }
 
 func GetGitDetails() (string, string, error) {
+	username, hasUsername := lookupAnswer(FlagUsername)
+	email, hasEmail := lookupAnswer(FlagEmail)
+	if hasUsername && hasEmail && username != "" && email != "" {
+		return username, email, nil
+	}
+	if !IsInteractive() {
+		if username == "" {
+			return "", "", &MissingAnswerError{Prompt: "Username", Flag: FlagUsername}
+		}
+		return "", "", &MissingAnswerError{Prompt: "Email", Flag: FlagEmail}
+	}
+
 	theme := huh.ThemeCatppuccin()
-	var username string
-	var email string
 
 	form := huh.NewForm(
 		huh.NewGroup(
@@ -91,55 +124,133 @@ type PRDetails struct {
 	Body      string
 	Labels    []string
 	Reviewers []string
+	Assignees []string
+	Milestone string
 	Draft     bool
 }
 
-func CreatePR(branchTitle, prTemplate string) (*PRDetails, error) {
-	theme := huh.ThemeCatppuccin()
+// CreatePR asks for the pull request details. Suggested reviewers (e.g. from
+// CODEOWNERS) are merged with whatever reviewers are entered.
+func CreatePR(branchTitle, prTemplate string, suggestedReviewers []string) (*PRDetails, error) {
+	details, err := prDetailsFromAnswers()
+	if err != nil {
+		return nil, err
+	}
+
+	if details.Body == "" && len(prTemplate) > 0 {
+		details.Body = strings.TrimSpace(prTemplate)
+	}
 
-	var title, body string
-	var tmpLabels, tmpReviewers string
-	var draft bool
+	if IsInteractive() {
+		tmpLabels := strings.Join(details.Labels, ", ")
+		tmpReviewers := strings.Join(details.Reviewers, ", ")
+		tmpAssignees := strings.Join(details.Assignees, ", ")
+		err := runCreatePRForm(branchTitle, details, suggestedReviewers, &tmpLabels, &tmpReviewers, &tmpAssignees)
+		if err != nil {
+			return nil, err
+		}
+		details.Labels = splitList(tmpLabels)
+		details.Reviewers = splitList(tmpReviewers)
+		details.Assignees = splitList(tmpAssignees)
+	}
 
-	if len(prTemplate) > 0 {
-		body = strings.TrimSpace(prTemplate)
+	if details.Title == "" {
+		details.Title = branchTitle
 	}
+	details.Reviewers = MergeReviewers(details.Reviewers, suggestedReviewers)
+
+	return details, nil
+}
 
-	var labels, reviewers []string
+func runCreatePRForm(branchTitle string, details *PRDetails, suggestedReviewers []string, tmpLabels, tmpReviewers, tmpAssignees *string) error {
+	theme := huh.ThemeCatppuccin()
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().Inline(true).
 				Title("Enter a title:").
 				Placeholder(branchTitle).
-				Value(&title),
+				Value(&details.Title),
 			huh.NewText().
 				Title("Enter a body:").
-				Value(&body),
+				Value(&details.Body),
 			huh.NewInput().Inline(true).
 				Title("Enter labels (comma separated):").
-				Value(&tmpLabels),
+				Value(tmpLabels),
+			huh.NewInput().Inline(true).
+				Title("Enter reviewers (comma separated, org/team for teams):").
+				Description(reviewerSuggestionText(suggestedReviewers)).
+				Value(tmpReviewers),
+			huh.NewInput().Inline(true).
+				Title("Enter assignees (comma separated):").
+				Value(tmpAssignees),
 			huh.NewInput().Inline(true).
-				Title("Enter reviewers (comma separated):").
-				Value(&tmpReviewers),
-			huh.NewConfirm().Title("Is this a draft PR?").Value(&draft),
+				Title("Enter a milestone:").
+				Value(&details.Milestone),
+			huh.NewConfirm().Title("Is this a draft PR?").Value(&details.Draft),
 		),
 	)
 
-	labels = strings.Split(tmpLabels, ",")
-	reviewers = strings.Split(tmpReviewers, ",")
+	return form.WithTheme(theme).Run()
+}
 
-	err := form.WithTheme(theme).Run()
+// MergeReviewers combines entered and suggested reviewers, dropping duplicates
+// regardless of case or a leading "@".
+func MergeReviewers(entered, suggested []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, reviewer := range append(append([]string{}, entered...), suggested...) {
+		reviewer = strings.TrimPrefix(strings.TrimSpace(reviewer), "@")
+		key := strings.ToLower(reviewer)
+		if reviewer == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, reviewer)
+	}
+	return merged
+}
 
-	if title == "" {
-		title = branchTitle
+func reviewerSuggestionText(suggested []string) string {
+	if len(suggested) == 0 {
+		return ""
 	}
+	return fmt.Sprintf("Code owners added automatically: %s", strings.Join(suggested, ", "))
+}
 
-	return &PRDetails{
-		title,
-		body,
-		labels,
-		reviewers,
-		draft,
-	}, err
+// SelectPRTemplate picks the PR template to use. A single template is used as
+// is; with several, the --template flag or a picker decides. It returns nil if
+// there are no templates.
+func SelectPRTemplate(templates []git.PRTemplate) (*git.PRTemplate, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	value, ok := lookupAnswer(FlagTemplate)
+	if !ok && len(templates) == 1 {
+		return &templates[0], nil
+	}
+	if ok {
+		// Names match case-insensitively, as in git.Repo.GetPRTemplateByName.
+		for i := range templates {
+			if strings.EqualFold(templates[i].Name, value) {
+				return &templates[i], nil
+			}
+		}
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+
+	name, err := Select(names, "Choose a pull request template:", WithFlag(FlagTemplate))
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		if strings.EqualFold(templates[i].Name, name) {
+			return &templates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pull request template %q not found", name)
 }